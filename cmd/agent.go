@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/kleytonsolinho/golang-stress-test/stresstest"
+	"github.com/spf13/cobra"
+)
+
+// agentCmd runs a worker that executes plans sent by a coordinator.
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run a distributed stress test agent",
+	Long: `Run an agent that waits for test plans from a coordinator started with
+run --agents, runs them locally and sends the results back. The agent only
+accepts plans from coordinators that send its token, since it runs tests
+against any URL they give.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen, _ := cmd.Flags().GetString("listen")
+		token, _ := cmd.Flags().GetString("token")
+		if token == "" {
+			token = os.Getenv("STRESS_AGENT_TOKEN")
+		}
+		if token == "" {
+			return errors.New("set a token shared with the coordinator with --token or $STRESS_AGENT_TOKEN")
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		fmt.Fprintln(os.Stderr, "Agent listening on", listen)
+		return stresstest.ServeAgent(ctx, listen, token)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+	agentCmd.Flags().StringP("listen", "l", "127.0.0.1:7070", "Address the agent listens on (use :7070 to accept coordinators from other hosts)")
+	agentCmd.Flags().String("token", "", "Token coordinators must send to run tests (default $STRESS_AGENT_TOKEN)")
+}
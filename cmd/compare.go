@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kleytonsolinho/golang-stress-test/stresstest"
+	"github.com/spf13/cobra"
+)
+
+// compareCmd diffs two saved reports and fails when the new one regressed.
+var compareCmd = &cobra.Command{
+	Use:   "compare BASE NEW",
+	Short: "Compare two saved JSON reports",
+	Long: `Compare two reports saved with run --save or run -f json, such as runs
+from before and after a deployment, showing how the latency percentiles, error
+rate and throughput changed. The command fails when a change is beyond one of the
+regression thresholds.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		maxLatencyIncrease, _ := cmd.Flags().GetFloat64("max-latency-increase")
+		maxErrorRateIncrease, _ := cmd.Flags().GetFloat64("max-error-rate-increase")
+		maxRateDecrease, _ := cmd.Flags().GetFloat64("max-rate-decrease")
+
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid format %q, use text or json", format)
+		}
+
+		base, err := stresstest.LoadReport(args[0])
+		if err != nil {
+			return err
+		}
+		next, err := stresstest.LoadReport(args[1])
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		comparison := stresstest.Compare(base, next)
+		regressionErr := comparison.Evaluate(stresstest.RegressionThresholds{
+			MaxLatencyIncrease:   maxLatencyIncrease,
+			MaxErrorRateIncrease: maxErrorRateIncrease,
+			MaxRateDecrease:      maxRateDecrease,
+		})
+
+		if format == "json" {
+			data, err := comparison.JSON()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		} else {
+			comparison.WriteText(cmd.OutOrStdout())
+		}
+		return regressionErr
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+	compareCmd.Flags().StringP("format", "f", "text", "Output format (text or json)")
+	compareCmd.Flags().Float64("max-latency-increase", 0, "Fail when a latency percentile grew by more than this percentage")
+	compareCmd.Flags().Float64("max-error-rate-increase", 0, "Fail when the error rate grew by more than this many percentage points")
+	compareCmd.Flags().Float64("max-rate-decrease", 0, "Fail when the achieved rate dropped by more than this percentage")
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is a stress.yaml file. Each profile is a named set of flag
+// values, keyed by flag name, so teams can commit standard test definitions:
+//
+//	profiles:
+//	  smoke:
+//	    url: https://api.example.com/health
+//	    concurrency: 5
+//	    requests: 100
+//	    header: ["Accept: application/json"]
+//	    max-p95: 300ms
+type configFile struct {
+	Profiles map[string]map[string]any `yaml:"profiles"`
+}
+
+// applyProfile sets the flags of the profile selected with --profile. Flags
+// given on the command line take precedence over the profile.
+func applyProfile(cmd *cobra.Command) error {
+	name, _ := cmd.Flags().GetString("profile")
+	if name == "" {
+		return nil
+	}
+	path, _ := cmd.Flags().GetString("config")
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !cmd.Flags().Changed("config") {
+		return fmt.Errorf("--profile needs a config file, %s not found", path)
+	}
+	if err != nil {
+		return err
+	}
+	var config configFile
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	profile, ok := config.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(config.Profiles))
+		for name := range config.Profiles {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return fmt.Errorf("%s: no profile %q (have %v)", path, name, names)
+	}
+
+	for key, value := range profile {
+		flag := cmd.Flags().Lookup(key)
+		if flag == nil || key == "profile" || key == "config" {
+			return fmt.Errorf("%s: profile %s: unknown flag %q", path, name, key)
+		}
+		if flag.Changed {
+			continue
+		}
+		values, ok := value.([]any)
+		if !ok {
+			values = []any{value}
+		}
+		for _, v := range values {
+			if err := cmd.Flags().Set(key, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("%s: profile %s: %s: %w", path, name, key, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kleytonsolinho/golang-stress-test/dnsstress"
+	"github.com/kleytonsolinho/golang-stress-test/stresstest"
+	"github.com/spf13/cobra"
+)
+
+// dnsCmd stress tests a DNS resolver.
+var dnsCmd = &cobra.Command{
+	Use:   "dns",
+	Short: "Stress test a DNS resolver",
+	Long: `Send DNS queries concurrently to a resolver, over UDP, TCP or DNS over
+HTTPS, and report latencies and response codes. Names and record types are
+queried in turn; with --template, names can get random labels to bypass the
+resolver's cache.`,
+	Example: `  golang-stress-test dns --server 127.0.0.1 --name example.com --type A --type AAAA -r 1000 -c 20
+  golang-stress-test dns --transport doh --server https://dns.example/dns-query \
+    --template --name '{{randString 8}}.example.com' --duration 30s -c 50`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		server, _ := cmd.Flags().GetString("server")
+		transport, _ := cmd.Flags().GetString("transport")
+		names, _ := cmd.Flags().GetStringArray("name")
+		types, _ := cmd.Flags().GetStringArray("type")
+		noRecursion, _ := cmd.Flags().GetBool("no-recursion")
+		insecure, _ := cmd.Flags().GetBool("insecure")
+		requests, _ := cmd.Flags().GetInt("requests")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		duration, _ := cmd.Flags().GetDuration("duration")
+		rate, _ := cmd.Flags().GetFloat64("rate")
+		timeoutValue, _ := cmd.Flags().GetString("timeout")
+		gracePeriod, _ := cmd.Flags().GetDuration("grace-period")
+		template, _ := cmd.Flags().GetBool("template")
+		format, _ := cmd.Flags().GetString("format")
+		tui, _ := cmd.Flags().GetBool("tui")
+
+		switch stresstest.ReportFormat(format) {
+		case stresstest.ReportFormatText, stresstest.ReportFormatJSON, stresstest.ReportFormatMarkdown:
+		default:
+			return fmt.Errorf("invalid format %q, expected text, json or markdown", format)
+		}
+		timeout, err := parseTimeout(timeoutValue)
+		if err != nil {
+			return err
+		}
+		querier, err := dnsstress.NewQuerier(dnsstress.Config{
+			Server:             server,
+			Transport:          dnsstress.Transport(strings.ToLower(transport)),
+			Names:              names,
+			Types:              types,
+			NoRecursion:        noRecursion,
+			InsecureSkipVerify: insecure,
+		})
+		if err != nil {
+			return err
+		}
+		defer querier.Close()
+
+		cmd.SilenceUsage = true
+
+		s := stresstest.New(server,
+			stresstest.WithMethod("QUERY"),
+			stresstest.WithConcurrency(concurrency),
+			stresstest.WithRequests(requests),
+			stresstest.WithTimeout(timeout),
+		)
+		ctx, stop := notifyShutdown(cmd.Context(), s)
+		defer stop()
+
+		s.WithCall(querier.Query)
+		s.WithDuration(duration)
+		s.WithRatePerSecond(rate)
+		s.WithTemplating(template)
+		s.WithGracePeriod(gracePeriod)
+		s.WithProgress(stresstest.IsTerminal(os.Stderr))
+		s.WithDashboard(tui)
+		s.WithReporter(statusReporter{w: os.Stderr})
+		s.WithReporter(stresstest.NewConsoleReporter(os.Stdout, stresstest.ReportFormat(format)))
+		return runWithStatus(ctx, s, os.Stderr)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dnsCmd)
+	dnsCmd.Flags().String("server", "", "Resolver address (host or host:port, port 53 by default), or the https URL of a DNS over HTTPS endpoint")
+	dnsCmd.Flags().String("transport", "udp", "How queries are sent (udp, tcp or doh)")
+	dnsCmd.Flags().StringArray("name", nil, "Name to query (repeatable, queried in turn)")
+	dnsCmd.Flags().StringArray("type", nil, "Record type to query for each name: A, AAAA, SRV, CNAME, MX, NS, PTR, SOA or TXT (repeatable, default A)")
+	dnsCmd.Flags().Bool("no-recursion", false, "Clear the recursion desired flag, to query an authoritative server")
+	dnsCmd.Flags().Bool("insecure", false, "Skip verification of the DNS over HTTPS server certificate")
+	dnsCmd.Flags().IntP("requests", "r", 1, "Number of queries to send")
+	dnsCmd.Flags().IntP("concurrency", "c", 1, "Number of concurrent queries")
+	dnsCmd.Flags().Duration("duration", 0, "Run for this long instead of a fixed number of queries")
+	dnsCmd.Flags().Float64("rate", 0, "Maximum queries per second across all workers (0 means unlimited)")
+	dnsCmd.Flags().Duration("grace-period", 5*time.Second, "On Ctrl+C, how long queries in flight may finish before they are aborted")
+	dnsCmd.Flags().String("timeout", "5s", "Query timeout, as a duration (750ms, 2s) or a number of seconds")
+	dnsCmd.Flags().Bool("template", false, "Expand {{...}} templates in the names")
+	dnsCmd.Flags().StringP("format", "f", "text", "Report format (text, json or markdown)")
+	dnsCmd.Flags().Bool("tui", false, "Show a live full screen dashboard instead of the progress line")
+	dnsCmd.MarkFlagRequired("server")
+	dnsCmd.MarkFlagRequired("name")
+	dnsCmd.MarkFlagsMutuallyExclusive("requests", "duration")
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kleytonsolinho/golang-stress-test/grpcstress"
+	"github.com/kleytonsolinho/golang-stress-test/stresstest"
+	"github.com/spf13/cobra"
+)
+
+// grpcCmd stress tests a unary gRPC method.
+var grpcCmd = &cobra.Command{
+	Use:   "grpc",
+	Short: "Stress test a unary gRPC method",
+	Long: `Call a unary gRPC method concurrently and report latencies and status
+codes. Request messages are given as JSON; their types come from a descriptor
+set (--descriptor-set) or, by default, from the server's reflection service.`,
+	Example: `  golang-stress-test grpc --target localhost:50051 --plaintext \
+    --method grpc.health.v1.Health/Check --data '{"service": ""}' -r 1000 -c 20`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, _ := cmd.Flags().GetString("target")
+		method, _ := cmd.Flags().GetString("method")
+		data, _ := cmd.Flags().GetString("data")
+		descriptorSet, _ := cmd.Flags().GetString("descriptor-set")
+		plaintext, _ := cmd.Flags().GetBool("plaintext")
+		insecure, _ := cmd.Flags().GetBool("insecure")
+		metadata, _ := cmd.Flags().GetStringArray("metadata")
+		requests, _ := cmd.Flags().GetInt("requests")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		duration, _ := cmd.Flags().GetDuration("duration")
+		rate, _ := cmd.Flags().GetFloat64("rate")
+		timeoutValue, _ := cmd.Flags().GetString("timeout")
+		gracePeriod, _ := cmd.Flags().GetDuration("grace-period")
+		template, _ := cmd.Flags().GetBool("template")
+		format, _ := cmd.Flags().GetString("format")
+		tui, _ := cmd.Flags().GetBool("tui")
+
+		switch stresstest.ReportFormat(format) {
+		case stresstest.ReportFormatText, stresstest.ReportFormatJSON, stresstest.ReportFormatMarkdown:
+		default:
+			return fmt.Errorf("invalid format %q, expected text, json or markdown", format)
+		}
+		timeout, err := parseTimeout(timeoutValue)
+		if err != nil {
+			return err
+		}
+		md := make(map[string][]string)
+		for _, pair := range metadata {
+			key, value, ok := strings.Cut(pair, ":")
+			if !ok {
+				return fmt.Errorf("invalid metadata %q, expected \"Key: Value\"", pair)
+			}
+			key = strings.TrimSpace(key)
+			md[key] = append(md[key], strings.TrimSpace(value))
+		}
+
+		cmd.SilenceUsage = true
+
+		s := stresstest.New(target,
+			stresstest.WithMethod(method),
+			stresstest.WithConcurrency(concurrency),
+			stresstest.WithRequests(requests),
+			stresstest.WithTimeout(timeout),
+		)
+		ctx, stop := notifyShutdown(cmd.Context(), s)
+		defer stop()
+
+		caller, err := grpcstress.NewCaller(ctx, grpcstress.Config{
+			Target:             target,
+			Method:             method,
+			Request:            data,
+			DescriptorSet:      descriptorSet,
+			Plaintext:          plaintext,
+			InsecureSkipVerify: insecure,
+			Metadata:           md,
+		})
+		if err != nil {
+			return err
+		}
+		defer caller.Close()
+
+		s.WithCall(caller.Call)
+		s.WithDuration(duration)
+		s.WithRatePerSecond(rate)
+		s.WithTemplating(template)
+		s.WithGracePeriod(gracePeriod)
+		s.WithProgress(stresstest.IsTerminal(os.Stderr))
+		s.WithDashboard(tui)
+		s.WithReporter(statusReporter{w: os.Stderr})
+		s.WithReporter(stresstest.NewConsoleReporter(os.Stdout, stresstest.ReportFormat(format)))
+		return runWithStatus(ctx, s, os.Stderr)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(grpcCmd)
+	grpcCmd.Flags().String("target", "", "Server address (host:port)")
+	grpcCmd.Flags().String("method", "", "Full method name (package.Service/Method)")
+	grpcCmd.Flags().String("data", "", "Request message as JSON")
+	grpcCmd.Flags().String("descriptor-set", "", "Descriptor set from protoc --descriptor_set_out --include_imports (default: server reflection)")
+	grpcCmd.Flags().Bool("plaintext", false, "Connect without TLS")
+	grpcCmd.Flags().Bool("insecure", false, "Skip verification of the server certificate")
+	grpcCmd.Flags().StringArrayP("metadata", "H", nil, "Metadata sent with every call, as \"Key: Value\" (repeatable)")
+	grpcCmd.Flags().IntP("requests", "r", 1, "Number of calls to make")
+	grpcCmd.Flags().IntP("concurrency", "c", 1, "Number of concurrent calls")
+	grpcCmd.Flags().Duration("duration", 0, "Run for this long instead of a fixed number of calls")
+	grpcCmd.Flags().Float64("rate", 0, "Maximum calls per second across all workers (0 means unlimited)")
+	grpcCmd.Flags().Duration("grace-period", 5*time.Second, "On Ctrl+C, how long calls in flight may finish before they are aborted")
+	grpcCmd.Flags().String("timeout", "30s", "Call timeout, as a duration (750ms, 2s) or a number of seconds")
+	grpcCmd.Flags().Bool("template", false, "Expand {{...}} templates in the request message")
+	grpcCmd.Flags().StringP("format", "f", "text", "Report format (text, json or markdown)")
+	grpcCmd.Flags().Bool("tui", false, "Show a live full screen dashboard instead of the progress line")
+	grpcCmd.MarkFlagRequired("target")
+	grpcCmd.MarkFlagRequired("method")
+	grpcCmd.MarkFlagsMutuallyExclusive("requests", "duration")
+}
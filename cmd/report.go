@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kleytonsolinho/golang-stress-test/stresstest"
+	"github.com/spf13/cobra"
+)
+
+// reportCmd renders a report saved with run --save, or run -f json, in
+// another format.
+var reportCmd = &cobra.Command{
+	Use:   "report FILE",
+	Short: "Render a saved JSON report as text, JSON, HTML or Markdown",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+
+		if format != "text" && format != "json" && format != "html" && format != "markdown" {
+			return fmt.Errorf("invalid format %q, use text, json, html or markdown", format)
+		}
+
+		report, err := stresstest.LoadReport(args[0])
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		var w io.Writer = os.Stdout
+		if output != "" {
+			file, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			w = file
+		}
+
+		switch format {
+		case "json":
+			data, err := report.JSON()
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(w, string(data))
+			return err
+		case "html":
+			return report.HTML(w)
+		case "markdown":
+			return report.Markdown(w)
+		default:
+			report.WriteText(w)
+			return nil
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().StringP("format", "f", "text", "Output format (text, json, html or markdown)")
+	reportCmd.Flags().StringP("output", "o", "", "Write to this file instead of stdout")
+}
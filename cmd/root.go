@@ -3,34 +3,20 @@ package cmd
 import (
 	"os"
 
-	"github.com/kleytonsolinho/golang-stress-test/stresstest"
 	"github.com/spf13/cobra"
 )
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "golang-stress-test",
-	Short: "A brief description of your application",
-	Long: `A longer description that spans multiple lines and likely contains
-examples and usage of using your application. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	// Uncomment the following line if your bare application
-	// has an action associated with it:
-	Run: func(cmd *cobra.Command, args []string) {
-		url, _ := cmd.Flags().GetString("url")
-		requests, _ := cmd.Flags().GetInt("requests")
-		concurrency, _ := cmd.Flags().GetInt("concurrency")
-
-		s := stresstest.NewStress(url, "GET", concurrency, requests, 30, false, false)
-		err := s.Run()
-		if err != nil {
-			panic(err)
-		}
-		s.PrintReport()
-	},
+	Short: "Stress test HTTP, WebSocket and gRPC services",
+	Long: `Stress test HTTP, WebSocket and gRPC services and report latency,
+throughput and errors. Start a test with the run command; saved JSON reports
+can be rendered again with report and compared with compare.
+
+The flags of run can also be given without a command, as in
+golang-stress-test --url=http://localhost:8080 --requests=1000 --concurrency=10,
+which runs the test like run does.`,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -41,21 +27,3 @@ func Execute() {
 		os.Exit(1)
 	}
 }
-
-func init() {
-	// Here you will define your flags and configuration settings.
-	// Cobra supports persistent flags, which, if defined here,
-	// will be global for your application.
-
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.golang-stress-test.yaml)")
-
-	// Cobra also supports local flags, which will only run
-	// when this action is called directly.
-	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
-	rootCmd.Flags().StringP("url", "u", "", "URL to stress test")
-	rootCmd.Flags().IntP("requests", "r", 1, "Number of requests to make")
-	rootCmd.Flags().IntP("concurrency", "c", 1, "Number of concurrent requests")
-	rootCmd.MarkFlagRequired("url")
-	rootCmd.MarkFlagRequired("requests")
-	rootCmd.MarkFlagRequired("concurrency")
-}
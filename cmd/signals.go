@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kleytonsolinho/golang-stress-test/stresstest"
+)
+
+// notifyShutdown returns a context cancelled on the first SIGINT or SIGTERM,
+// so s stops scheduling requests, lets those in flight finish within its
+// grace period and still reports. A second signal aborts them at once.
+func notifyShutdown(parent context.Context, s *stresstest.Stress) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case _, ok := <-signals:
+			if !ok {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+		fmt.Fprintln(os.Stderr, "\nStopping, waiting for requests in flight (press Ctrl+C again to abort them)")
+		cancel()
+
+		if _, ok := <-signals; ok {
+			fmt.Fprintln(os.Stderr, "Aborting requests in flight")
+			s.Abort()
+		}
+	}()
+
+	return ctx, func() {
+		cancel()
+		signal.Stop(signals)
+		close(signals)
+	}
+}
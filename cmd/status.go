@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/kleytonsolinho/golang-stress-test/stresstest"
+)
+
+// statusReporter tells on w, usually stderr, when a local run ends. Register
+// it before the other reporters so the line comes before the report.
+type statusReporter struct {
+	w io.Writer
+}
+
+func (r statusReporter) Collect(stresstest.Sample) {}
+
+func (r statusReporter) Finalize(*stresstest.StressReport) error {
+	_, err := fmt.Fprintln(r.w, "Finished stress test")
+	return err
+}
+
+// runWithStatus runs s once it is known to be valid, saying so on w. The end
+// is announced by a statusReporter.
+func runWithStatus(ctx context.Context, s *stresstest.Stress, w io.Writer) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "Running stress test...")
+	_, err := s.Run(ctx)
+	return err
+}
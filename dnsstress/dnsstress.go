@@ -0,0 +1,338 @@
+// Package dnsstress sends DNS queries through the stresstest scheduler, so a
+// resolver gets the same concurrency, rate, duration and reporting options as
+// HTTP tests. Queries go over UDP, TCP or DNS over HTTPS (RFC 8484), and the
+// response code of each answer is reported in place of the HTTP status.
+package dnsstress
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kleytonsolinho/golang-stress-test/stresstest"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Transport is how queries reach the server.
+type Transport string
+
+const (
+	TransportUDP Transport = "udp"
+	TransportTCP Transport = "tcp"
+	// TransportDoH posts the queries to a DNS over HTTPS endpoint.
+	TransportDoH Transport = "doh"
+)
+
+// Config describes the queries to send.
+type Config struct {
+	// Server is the resolver, as host or host:port (port 53 by default), or
+	// the https URL of the endpoint with TransportDoH.
+	Server    string
+	Transport Transport
+	// Names are the names to query, in turn. They are templates when
+	// templating is enabled on the Stress running the queries, to query
+	// random subdomains that bypass the cache for instance.
+	Names []string
+	// Types are the record types to query for each name, such as A, AAAA or
+	// SRV; A when empty.
+	Types []string
+	// NoRecursion clears the recursion desired flag, to query an
+	// authoritative server.
+	NoRecursion bool
+	// InsecureSkipVerify skips the verification of the certificate of a DoH
+	// server.
+	InsecureSkipVerify bool
+}
+
+var types = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"NS":    dnsmessage.TypeNS,
+	"PTR":   dnsmessage.TypePTR,
+	"SOA":   dnsmessage.TypeSOA,
+	"SRV":   dnsmessage.TypeSRV,
+	"TXT":   dnsmessage.TypeTXT,
+}
+
+// rcodes names the response codes the way dig prints them.
+var rcodes = map[dnsmessage.RCode]string{
+	dnsmessage.RCodeSuccess:        "NOERROR",
+	dnsmessage.RCodeFormatError:    "FORMERR",
+	dnsmessage.RCodeServerFailure:  "SERVFAIL",
+	dnsmessage.RCodeNameError:      "NXDOMAIN",
+	dnsmessage.RCodeNotImplemented: "NOTIMP",
+	dnsmessage.RCodeRefused:        "REFUSED",
+}
+
+type question struct {
+	name  string
+	qtype dnsmessage.Type
+}
+
+// Querier sends the queries described by a Config.
+type Querier struct {
+	transport Transport
+	server    string
+	questions []question
+	recursion bool
+	next      atomic.Uint64
+	client    *http.Client
+
+	// idle holds the TCP connections between two queries.
+	mu   sync.Mutex
+	idle []net.Conn
+}
+
+// NewQuerier checks the configuration. Close releases the connections.
+func NewQuerier(cfg Config) (*Querier, error) {
+	q := &Querier{transport: cfg.Transport, server: cfg.Server, recursion: !cfg.NoRecursion}
+	switch cfg.Transport {
+	case TransportUDP, TransportTCP, "":
+		if q.transport == "" {
+			q.transport = TransportUDP
+		}
+		if _, _, err := net.SplitHostPort(q.server); err != nil {
+			q.server = net.JoinHostPort(q.server, "53")
+		}
+	case TransportDoH:
+		u, err := url.Parse(cfg.Server)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("DNS over HTTPS needs an https URL, not %q", cfg.Server)
+		}
+		q.client = &http.Client{Transport: &http.Transport{
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify},
+			ForceAttemptHTTP2:   true,
+			MaxIdleConnsPerHost: 100,
+		}}
+	default:
+		return nil, fmt.Errorf("unknown transport %q, expected udp, tcp or doh", cfg.Transport)
+	}
+
+	if len(cfg.Names) == 0 {
+		return nil, errors.New("no name to query")
+	}
+	queryTypes := cfg.Types
+	if len(queryTypes) == 0 {
+		queryTypes = []string{"A"}
+	}
+	for _, name := range cfg.Names {
+		for _, typeName := range queryTypes {
+			qtype, ok := types[strings.ToUpper(typeName)]
+			if !ok {
+				return nil, fmt.Errorf("unknown record type %q", typeName)
+			}
+			q.questions = append(q.questions, question{name: name, qtype: qtype})
+		}
+	}
+	return q, nil
+}
+
+func (q *Querier) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, conn := range q.idle {
+		conn.Close()
+	}
+	q.idle = nil
+	if q.client != nil {
+		q.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// Query sends one query, for the next name and type in turn. The response
+// code is reported in place of the HTTP status, so NOERROR shows up as 0 and
+// every other code fails the query.
+func (q *Querier) Query(ctx context.Context, call stresstest.Call) (stresstest.CallResult, error) {
+	question := q.questions[(q.next.Add(1)-1)%uint64(len(q.questions))]
+	name, err := call.Expand(question.name)
+	if err != nil {
+		return stresstest.CallResult{}, err
+	}
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return stresstest.CallResult{}, fmt.Errorf("name %q: %w", name, err)
+	}
+
+	var id uint16
+	if q.transport != TransportDoH {
+		// DoH queries use ID 0, which keeps them cacheable.
+		var b [2]byte
+		rand.Read(b[:])
+		id = binary.BigEndian.Uint16(b[:])
+	}
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: q.recursion},
+		Questions: []dnsmessage.Question{{Name: qname, Type: question.qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		return stresstest.CallResult{}, err
+	}
+
+	var answer []byte
+	switch q.transport {
+	case TransportUDP:
+		answer, err = q.exchangeUDP(ctx, packed, id)
+	case TransportTCP:
+		answer, err = q.exchangeTCP(ctx, packed)
+	case TransportDoH:
+		answer, err = q.exchangeDoH(ctx, packed)
+	}
+	if err != nil {
+		return stresstest.CallResult{}, err
+	}
+
+	var parser dnsmessage.Parser
+	header, err := parser.Start(answer)
+	if err != nil {
+		return stresstest.CallResult{}, fmt.Errorf("invalid answer: %w", err)
+	}
+	if header.ID != id || !header.Response {
+		return stresstest.CallResult{}, errors.New("answer does not match the query")
+	}
+	result := stresstest.CallResult{Status: int(header.RCode), Protocol: "dns/" + string(q.transport)}
+	if header.RCode != dnsmessage.RCodeSuccess {
+		result.Failed = fmt.Errorf("%s for %s %s", rcodeName(header.RCode), strings.TrimPrefix(question.qtype.String(), "Type"), name)
+	}
+	return result, nil
+}
+
+func rcodeName(rcode dnsmessage.RCode) string {
+	if name, ok := rcodes[rcode]; ok {
+		return name
+	}
+	return fmt.Sprintf("RCODE%d", rcode)
+}
+
+// exchangeUDP sends the query from a socket of its own, so answers to other
+// queries never get in the way, and returns the answer with the query's ID.
+func (q *Querier) exchangeUDP(ctx context.Context, query []byte, id uint16) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", q.server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, contextErr(ctx, err)
+		}
+		// Answers with another ID were not asked for by this socket.
+		if n >= 2 && binary.BigEndian.Uint16(buf) == id {
+			return buf[:n], nil
+		}
+	}
+}
+
+// exchangeTCP sends the query over a pooled connection, one query at a
+// time, each prefixed with its length.
+func (q *Querier) exchangeTCP(ctx context.Context, query []byte) ([]byte, error) {
+	conn, err := q.tcpConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+	framed = append(framed, query...)
+	if _, err := conn.Write(framed); err != nil {
+		conn.Close()
+		return nil, contextErr(ctx, err)
+	}
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		conn.Close()
+		return nil, contextErr(ctx, err)
+	}
+	answer := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, answer); err != nil {
+		conn.Close()
+		return nil, contextErr(ctx, err)
+	}
+	if !stop() {
+		// The context ended while the answer was read.
+		return nil, ctx.Err()
+	}
+	conn.SetDeadline(time.Time{})
+	q.mu.Lock()
+	q.idle = append(q.idle, conn)
+	q.mu.Unlock()
+	return answer, nil
+}
+
+func (q *Querier) tcpConn(ctx context.Context) (net.Conn, error) {
+	q.mu.Lock()
+	if n := len(q.idle); n > 0 {
+		conn := q.idle[n-1]
+		q.idle = q.idle[:n-1]
+		q.mu.Unlock()
+		return conn, nil
+	}
+	q.mu.Unlock()
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", q.server)
+}
+
+func (q *Querier) exchangeDoH(ctx context.Context, query []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, q.server, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	res, err := q.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	answer, err := io.ReadAll(io.LimitReader(res.Body, 65535))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DoH server answered %s", res.Status)
+	}
+	return answer, nil
+}
+
+// contextErr returns the error of ctx when it is what made err happen, so
+// timeouts are reported as such.
+func contextErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
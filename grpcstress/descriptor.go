@@ -0,0 +1,100 @@
+package grpcstress
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func loadDescriptorSet(path string) (*protoregistry.Files, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("descriptor set %s: %w", path, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("descriptor set %s: %w", path, err)
+	}
+	return files, nil
+}
+
+// reflectFiles asks the server's reflection service for the file declaring
+// service, then for each of its dependencies that did not come along with it.
+func reflectFiles(ctx context.Context, conn *grpc.ClientConn, service string) (*protoregistry.Files, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reflection: %w", err)
+	}
+	defer stream.CloseSend()
+
+	byName := make(map[string]*descriptorpb.FileDescriptorProto)
+	receive := func(req *reflectionpb.ServerReflectionRequest) error {
+		if err := stream.Send(req); err != nil {
+			return fmt.Errorf("reflection: %w", err)
+		}
+		res, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("reflection: %w", err)
+		}
+		if e := res.GetErrorResponse(); e != nil {
+			return fmt.Errorf("reflection: %s", e.GetErrorMessage())
+		}
+		for _, raw := range res.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			file := new(descriptorpb.FileDescriptorProto)
+			if err := proto.Unmarshal(raw, file); err != nil {
+				return fmt.Errorf("reflection: %w", err)
+			}
+			byName[file.GetName()] = file
+		}
+		return nil
+	}
+
+	err = receive(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for missing := missingDependencies(byName); len(missing) > 0; missing = missingDependencies(byName) {
+		for _, name := range missing {
+			err := receive(&reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := byName[name]; !ok {
+				return nil, fmt.Errorf("reflection: server did not return %s", name)
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range byName {
+		set.File = append(set.File, file)
+	}
+	return protodesc.NewFiles(set)
+}
+
+func missingDependencies(byName map[string]*descriptorpb.FileDescriptorProto) []string {
+	var missing []string
+	for _, file := range byName {
+		for _, dep := range file.GetDependency() {
+			if _, ok := byName[dep]; !ok {
+				missing = append(missing, dep)
+			}
+		}
+	}
+	return missing
+}
@@ -0,0 +1,157 @@
+// Package grpcstress runs unary gRPC calls through the stresstest scheduler,
+// so they get the same concurrency, rate, duration and reporting options as
+// HTTP tests. Messages are built at run time from JSON, using either a
+// descriptor set file or the server's reflection service to learn their
+// types, so no generated code is needed.
+package grpcstress
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kleytonsolinho/golang-stress-test/stresstest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Config describes the calls to make.
+type Config struct {
+	// Target is the server address, as host:port.
+	Target string
+	// Method is the full method name, as package.Service/Method.
+	Method string
+	// Request is the JSON request message. It is a template when templating
+	// is enabled on the Stress running the calls.
+	Request string
+	// DescriptorSet is a file produced by protoc --descriptor_set_out
+	// --include_imports. When empty the server's reflection service is used.
+	DescriptorSet string
+	// Plaintext disables TLS. Otherwise InsecureSkipVerify skips verification
+	// of the server certificate.
+	Plaintext          bool
+	InsecureSkipVerify bool
+	// Metadata is sent with every call.
+	Metadata map[string][]string
+}
+
+// Caller makes the calls described by a Config.
+type Caller struct {
+	conn     *grpc.ClientConn
+	method   string
+	input    protoreflect.MessageDescriptor
+	output   protoreflect.MessageDescriptor
+	request  string
+	metadata metadata.MD
+}
+
+// NewCaller connects to the target and resolves the method. Close releases
+// the connection.
+func NewCaller(ctx context.Context, cfg Config) (*Caller, error) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(cfg.Method, "/"), "/")
+	if !ok || service == "" || method == "" {
+		return nil, fmt.Errorf("method %q must be package.Service/Method", cfg.Method)
+	}
+
+	creds := insecure.NewCredentials()
+	if !cfg.Plaintext {
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify})
+	}
+	conn, err := grpc.NewClient(cfg.Target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+
+	var files *protoregistry.Files
+	if cfg.DescriptorSet != "" {
+		files, err = loadDescriptorSet(cfg.DescriptorSet)
+	} else {
+		files, err = reflectFiles(ctx, conn, service)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("service %s: %w", service, err)
+	}
+	serviceDesc, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	methodDesc := serviceDesc.Methods().ByName(protoreflect.Name(method))
+	if methodDesc == nil {
+		conn.Close()
+		return nil, fmt.Errorf("service %s has no method %s", service, method)
+	}
+	if methodDesc.IsStreamingClient() || methodDesc.IsStreamingServer() {
+		conn.Close()
+		return nil, fmt.Errorf("method %s is streaming, only unary methods are supported", cfg.Method)
+	}
+
+	md := metadata.MD{}
+	for key, values := range cfg.Metadata {
+		md.Append(key, values...)
+	}
+
+	return &Caller{
+		conn:     conn,
+		method:   "/" + service + "/" + method,
+		input:    methodDesc.Input(),
+		output:   methodDesc.Output(),
+		request:  cfg.Request,
+		metadata: md,
+	}, nil
+}
+
+func (c *Caller) Close() error {
+	return c.conn.Close()
+}
+
+// Call makes one unary call. The gRPC status code is reported in place of
+// the HTTP status, so OK shows up as 0 and every other code fails the call.
+func (c *Caller) Call(ctx context.Context, call stresstest.Call) (stresstest.CallResult, error) {
+	text, err := call.Expand(c.request)
+	if err != nil {
+		return stresstest.CallResult{}, err
+	}
+	req := dynamicpb.NewMessage(c.input)
+	if text != "" {
+		if err := protojson.Unmarshal([]byte(text), req); err != nil {
+			return stresstest.CallResult{}, fmt.Errorf("request: %w", err)
+		}
+	}
+
+	if len(c.metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, c.metadata)
+	}
+	err = c.conn.Invoke(ctx, c.method, req, dynamicpb.NewMessage(c.output))
+
+	st, _ := status.FromError(err)
+	switch st.Code() {
+	case codes.OK:
+		return stresstest.CallResult{Status: int(codes.OK), Protocol: "grpc"}, nil
+	case codes.DeadlineExceeded:
+		// Reported as a timeout rather than as a status.
+		return stresstest.CallResult{}, context.DeadlineExceeded
+	}
+	return stresstest.CallResult{
+		Status:   int(st.Code()),
+		Protocol: "grpc",
+		Failed:   errors.New(st.Code().String() + ": " + st.Message()),
+	}, nil
+}
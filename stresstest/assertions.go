@@ -0,0 +1,136 @@
+package stresstest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// HeaderAssertion checks a response header, such as Cache-Control or
+// X-RateLimit-Remaining. With neither Equals nor Matches set, the header only
+// has to be present.
+type HeaderAssertion struct {
+	Name string
+	// Equals is the value the header must have.
+	Equals string
+	// Matches must match the value of the header.
+	Matches *regexp.Regexp
+}
+
+// ParseHeaderAssertion parses specs such as "ETag" (present),
+// "Cache-Control=no-cache" (equal to) or "X-RateLimit-Remaining~^[0-9]+$"
+// (matching the regular expression).
+func ParseHeaderAssertion(spec string) (HeaderAssertion, error) {
+	i := strings.IndexAny(spec, "=~")
+	if i < 0 {
+		name := strings.TrimSpace(spec)
+		if name == "" {
+			return HeaderAssertion{}, fmt.Errorf("invalid header assertion %q", spec)
+		}
+		return HeaderAssertion{Name: name}, nil
+	}
+
+	assertion := HeaderAssertion{Name: strings.TrimSpace(spec[:i])}
+	if assertion.Name == "" {
+		return HeaderAssertion{}, fmt.Errorf("invalid header assertion %q, no header name", spec)
+	}
+	value := spec[i+1:]
+	if spec[i] == '=' {
+		assertion.Equals = value
+		return assertion, nil
+	}
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return HeaderAssertion{}, fmt.Errorf("invalid header assertion %q: %w", spec, err)
+	}
+	assertion.Matches = re
+	return assertion, nil
+}
+
+// String describes the assertion the way ParseHeaderAssertion reads it.
+func (a HeaderAssertion) String() string {
+	switch {
+	case a.Matches != nil:
+		return a.Name + "~" + a.Matches.String()
+	case a.Equals != "":
+		return a.Name + "=" + a.Equals
+	}
+	return a.Name
+}
+
+// check returns why header does not meet the assertion, or nil.
+func (a HeaderAssertion) check(header http.Header) error {
+	values := header.Values(a.Name)
+	if len(values) == 0 {
+		return fmt.Errorf("header %s missing", a.Name)
+	}
+	value := strings.Join(values, ", ")
+	if a.Equals != "" && value != a.Equals {
+		return fmt.Errorf("header %s is %q, not %q", a.Name, value, a.Equals)
+	}
+	if a.Matches != nil && !a.Matches.MatchString(value) {
+		return fmt.Errorf("header %s %q does not match %q", a.Name, value, a.Matches)
+	}
+	return nil
+}
+
+// assertionError marks a response that failed a header assertion.
+type assertionError struct {
+	assertion HeaderAssertion
+	err       error
+}
+
+func (e *assertionError) Error() string { return e.err.Error() }
+
+func (e *assertionError) Unwrap() error { return e.err }
+
+// WithHeaderAssertions checks the headers of every response that met the
+// success criteria. A response failing one fails the request, and the report
+// counts the failures of each assertion in AssertionFailures.
+func (s *Stress) WithHeaderAssertions(assertions ...HeaderAssertion) *Stress {
+	s.HeaderAssertions = append(s.HeaderAssertions, assertions...)
+	return s
+}
+
+// checkHeaders returns the failure of the first assertion res does not meet,
+// or nil.
+func (s *Stress) checkHeaders(res *http.Response) error {
+	for _, assertion := range s.HeaderAssertions {
+		if err := assertion.check(res.Header); err != nil {
+			return &checkError{kind: ErrorKindAssertion, err: &assertionError{assertion: assertion, err: err}}
+		}
+	}
+	return nil
+}
+
+func (r *StressReport) addAssertionFailure(assertion HeaderAssertion) {
+	if r.AssertionFailures == nil {
+		r.AssertionFailures = make(map[string]int)
+	}
+	r.AssertionFailures[assertion.String()]++
+}
+
+// writeAssertionFailures lists the failed assertions, most frequent first.
+func (r *StressReport) writeAssertionFailures(w io.Writer) {
+	if len(r.AssertionFailures) == 0 {
+		return
+	}
+	assertions := make([]string, 0, len(r.AssertionFailures))
+	for assertion := range r.AssertionFailures {
+		assertions = append(assertions, assertion)
+	}
+	slices.SortFunc(assertions, func(a, b string) int {
+		if r.AssertionFailures[a] != r.AssertionFailures[b] {
+			return r.AssertionFailures[b] - r.AssertionFailures[a]
+		}
+		return strings.Compare(a, b)
+	})
+
+	fmt.Fprintln(w, "--- Assertion failures ---")
+	for _, assertion := range assertions {
+		fmt.Fprintln(w, assertion+":", r.AssertionFailures[assertion], "requests")
+	}
+}
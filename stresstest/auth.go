@@ -0,0 +1,126 @@
+package stresstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryMargin refreshes OAuth2 tokens a little before they expire so no
+// request goes out with a token that dies on the way.
+const tokenExpiryMargin = 30 * time.Second
+
+type authenticator interface {
+	apply(req *http.Request) error
+}
+
+type basicAuth struct {
+	username string
+	password string
+}
+
+func (a basicAuth) apply(req *http.Request) error {
+	req.SetBasicAuth(a.username, a.password)
+	return nil
+}
+
+type bearerToken string
+
+func (t bearerToken) apply(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return nil
+}
+
+// clientCredentials implements the OAuth2 client credentials grant. The token
+// is shared by all workers and fetched again when it is about to expire.
+type clientCredentials struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	client       func() *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (c *clientCredentials) apply(req *http.Request) error {
+	token, err := c.currentToken()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (c *clientCredentials) currentToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && (c.expires.IsZero() || time.Now().Before(c.expires)) {
+		return c.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequest(http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret))
+
+	res, err := c.client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oauth2 token: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oauth2 token: unexpected status %d", res.StatusCode)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("oauth2 token: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", fmt.Errorf("oauth2 token: no access_token in response")
+	}
+
+	c.token = body.AccessToken
+	c.expires = time.Time{}
+	if body.ExpiresIn > 0 {
+		c.expires = time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - tokenExpiryMargin)
+	}
+	return c.token, nil
+}
+
+func (s *Stress) WithBasicAuth(username string, password string) *Stress {
+	s.auth = basicAuth{username: username, password: password}
+	return s
+}
+
+func (s *Stress) WithBearerToken(token string) *Stress {
+	s.auth = bearerToken(token)
+	return s
+}
+
+// WithOAuth2ClientCredentials fetches an access token from tokenURL with the
+// client credentials grant and sends it as a bearer token, refreshing it when
+// it expires during long runs.
+func (s *Stress) WithOAuth2ClientCredentials(tokenURL string, clientID string, clientSecret string) *Stress {
+	s.auth = &clientCredentials{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		client:       func() *http.Client { return s.client },
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+package stresstest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	mathrand "math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// BodyFunc returns a fresh request body. It is called once per request so
+// concurrent workers never share a reader that has already been consumed.
+type BodyFunc func() (io.Reader, error)
+
+func BodyFromString(body string) BodyFunc {
+	return func() (io.Reader, error) {
+		return strings.NewReader(body), nil
+	}
+}
+
+func BodyFromBytes(body []byte) BodyFunc {
+	return func() (io.Reader, error) {
+		return bytes.NewReader(body), nil
+	}
+}
+
+// BodyFromFile reads the file once, on first use, and serves its contents
+// to every request after that.
+func BodyFromFile(path string) BodyFunc {
+	var once sync.Once
+	var data []byte
+	var err error
+
+	return func() (io.Reader, error) {
+		once.Do(func() {
+			data, err = os.ReadFile(path)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(data), nil
+	}
+}
+
+// BodyRandom sends size random bytes, different for every request, for
+// instance to test bandwidth or payload size limits without a file.
+func BodyRandom(size int) BodyFunc {
+	return func() (io.Reader, error) {
+		b := make([]byte, size)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(b), nil
+	}
+}
+
+// BodyRandomJSON sends a random JSON document of size bytes, different for
+// every request: an array of small objects padded to the exact size. Sizes
+// below 21 bytes give the smallest such document.
+func BodyRandomJSON(size int) BodyFunc {
+	return func() (io.Reader, error) {
+		return bytes.NewReader(randomJSON(size)), nil
+	}
+}
+
+const randomLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func randomJSON(size int) []byte {
+	const tail = `],"pad":""}`
+	b := make([]byte, 0, size)
+	b = append(b, `{"items":[`...)
+	for i := 0; ; i++ {
+		item := `{"id":` + strconv.Itoa(i) + `,"name":"` + randomString(8) + `","score":` + strconv.Itoa(mathrand.Intn(1000)) + `}`
+		if i > 0 {
+			item = "," + item
+		}
+		if len(b)+len(item)+len(tail) > size {
+			break
+		}
+		b = append(b, item...)
+	}
+	b = append(b, `],"pad":"`...)
+	b = append(b, randomString(size-len(b)-2)...)
+	return append(b, `"}`...)
+}
+
+func randomString(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = randomLetters[mathrand.Intn(len(randomLetters))]
+	}
+	return string(b)
+}
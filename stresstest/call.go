@@ -0,0 +1,95 @@
+package stresstest
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// CallFunc performs one iteration of a test that does not speak HTTP, such as
+// a gRPC call, so it can reuse the scheduling and reporting of a Stress. It
+// returns an error only when no response was received at all.
+type CallFunc func(ctx context.Context, call Call) (CallResult, error)
+
+// Call describes the iteration a CallFunc is asked to perform.
+type Call struct {
+	Worker int
+	s      *Stress
+	vu     *VirtualUser
+	data   map[string]string
+}
+
+// Expand renders text with the same template functions and feeder row as an
+// HTTP request would get. It returns text unchanged when templating is off.
+func (c Call) Expand(text string) (string, error) {
+	if !c.s.Templating {
+		return text, nil
+	}
+	return c.vu.templates.expand(text, c.data)
+}
+
+// CallResult is the response to a call. Status takes the place of the HTTP
+// status code in the report and Failed is set when the response means the
+// call did not succeed.
+type CallResult struct {
+	Status   int
+	Protocol string
+	Failed   error
+}
+
+// WithCall replaces the HTTP request of each iteration with fn.
+func (s *Stress) WithCall(fn CallFunc) *Stress {
+	s.Call = fn
+	return s
+}
+
+// runCall runs one iteration through s.Call. As with HTTP requests, a call
+// that has started is only aborted once the grace period is over.
+func (s *Stress) runCall(ctx context.Context, worker int, spec requestSpec) {
+	if s.limiter != nil && spec.Scheduled.IsZero() {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return
+		}
+	}
+
+	vu := s.virtualUser(worker)
+	call := Call{Worker: worker, s: s, vu: vu, data: s.templateData(vu)}
+
+	callCtx, cancel := context.WithTimeout(s.inFlight, s.Timeout)
+	defer cancel()
+
+	s.addInFlight(1)
+	start := s.now()
+	result, err := s.Call(callCtx, call)
+	elapsed := s.since(start)
+	s.addInFlight(-1)
+	failure := err
+	if failure == nil {
+		failure = result.Failed
+	}
+	if s.aborted(failure) {
+		return
+	}
+
+	sample := Sample{Start: start, Worker: worker, Target: spec.Label, Method: spec.Method, URL: spec.URL, Latency: elapsed, Err: err, CheckErr: result.Failed}
+	var res *http.Response
+	if err == nil {
+		sample.Status = result.Status
+		res = &http.Response{StatusCode: result.Status, Proto: result.Protocol}
+	}
+	s.collect(sample)
+
+	if s.ExcludeRampUp && start.Before(s.measureFrom) {
+		s.mu.Lock()
+		s.Report.WarmUpRequests++
+		s.mu.Unlock()
+		return
+	}
+	if !spec.Scheduled.IsZero() {
+		s.mu.Lock()
+		s.Report.addCorrectedLatency(time.Since(spec.Scheduled))
+		s.mu.Unlock()
+	}
+
+	s.updateReport(spec, res, err, result.Failed, elapsed)
+}
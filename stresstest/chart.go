@@ -0,0 +1,96 @@
+package stresstest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	chartHeight   = 8
+	chartMaxWidth = 60
+)
+
+// writeTimeCharts renders the average latency, the rate and the new
+// connections of every second of the run as bar charts, so warm-ups,
+// degradation, pauses of the server or connection churn show without
+// exporting the time series. Long runs are drawn with several seconds per
+// column.
+func (r *StressReport) writeTimeCharts(w io.Writer) {
+	if len(r.TimeSeries) < 2 {
+		return
+	}
+
+	step := (len(r.TimeSeries) + chartMaxWidth - 1) / chartMaxWidth
+	var latencies, rates, connections []float64
+	churn := false
+	for start := 0; start < len(r.TimeSeries); start += step {
+		points := r.TimeSeries[start:min(start+step, len(r.TimeSeries))]
+		requests, rate, latency, opened := 0, 0.0, 0.0, 0
+		for _, point := range points {
+			requests += point.Requests
+			rate += point.RPS
+			latency += point.AverageLatency * float64(point.Requests)
+			opened += point.NewConnections
+		}
+		if requests > 0 {
+			latency /= float64(requests)
+		}
+		latencies = append(latencies, latency)
+		rates = append(rates, rate/float64(len(points)))
+		connections = append(connections, float64(opened)/float64(len(points)))
+		churn = churn || (start > 0 && opened > 0)
+	}
+
+	fmt.Fprintln(w, "--- Average latency over time (ms) ---")
+	writeChart(w, latencies, step, len(r.TimeSeries))
+	fmt.Fprintln(w, "--- Requests per second over time ---")
+	writeChart(w, rates, step, len(r.TimeSeries))
+	// Connections opened only at the start are the pool filling up, not churn.
+	if churn {
+		fmt.Fprintln(w, "--- New connections per second over time ---")
+		writeChart(w, connections, step, len(r.TimeSeries))
+	}
+}
+
+// writeChart draws values as columns chartHeight rows high, scaled to the
+// largest one, with eighth blocks for the tops.
+func writeChart(w io.Writer, values []float64, step int, seconds int) {
+	peak := 0.0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	for row := chartHeight - 1; row >= 0; row-- {
+		label := ""
+		switch row {
+		case chartHeight - 1:
+			label = fmt.Sprintf("%.2f", peak)
+		case 0:
+			label = "0"
+		}
+		var line strings.Builder
+		for _, v := range values {
+			eighths := 0
+			if peak > 0 {
+				eighths = int(v / peak * chartHeight * 8)
+			}
+			switch full := eighths / 8; {
+			case full > row:
+				line.WriteRune('█')
+			case full == row && eighths%8 > 0:
+				line.WriteRune(sparkTicks[eighths%8-1])
+			default:
+				line.WriteRune(' ')
+			}
+		}
+		fmt.Fprintf(w, "%10s ┤%s\n", label, line.String())
+	}
+	fmt.Fprintf(w, "%10s └%s\n", "", strings.Repeat("─", len(values)))
+	end := fmt.Sprintf("%ds", seconds)
+	gap := strings.Repeat(" ", max(len(values)-len("1s")-len(end), 1))
+	if step > 1 {
+		end += fmt.Sprintf(" (%ds per column)", step)
+	}
+	fmt.Fprintf(w, "%10s  1s%s%s\n", "", gap, end)
+}
@@ -0,0 +1,90 @@
+package stresstest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// Checksum is the expected hash of response bodies, to detect truncated or
+// corrupted responses, for instance from a CDN serving static assets.
+type Checksum struct {
+	// Algorithm is md5, sha1, sha256 or sha512.
+	Algorithm string `json:"algorithm"`
+	// Sum is the hex encoded hash.
+	Sum string `json:"sum"`
+}
+
+// ParseChecksum parses specs such as "sha256:9f86d08...".
+func ParseChecksum(spec string) (Checksum, error) {
+	algorithm, sum, ok := strings.Cut(spec, ":")
+	if !ok {
+		return Checksum{}, fmt.Errorf("invalid checksum %q, expected ALGORITHM:HEX", spec)
+	}
+	c := Checksum{Algorithm: strings.ToLower(algorithm), Sum: strings.ToLower(sum)}
+	if err := c.validate(); err != nil {
+		return Checksum{}, err
+	}
+	return c, nil
+}
+
+func (c Checksum) String() string {
+	return c.Algorithm + ":" + c.Sum
+}
+
+func (c Checksum) validate() error {
+	h := c.newHash()
+	if h == nil {
+		return fmt.Errorf("unknown checksum algorithm %q, expected md5, sha1, sha256 or sha512", c.Algorithm)
+	}
+	sum, err := hex.DecodeString(c.Sum)
+	if err != nil || len(sum) != h.Size() {
+		return fmt.Errorf("invalid %s checksum %q", c.Algorithm, c.Sum)
+	}
+	return nil
+}
+
+func (c Checksum) newHash() hash.Hash {
+	switch strings.ToLower(c.Algorithm) {
+	case "md5":
+		return md5.New()
+	case "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	case "sha512":
+		return sha512.New()
+	}
+	return nil
+}
+
+// check returns why sum, the hash of a body, is not the expected one, or nil.
+func (c Checksum) check(sum []byte) error {
+	want, _ := hex.DecodeString(c.Sum)
+	if !bytes.Equal(sum, want) {
+		return &checkError{kind: ErrorKindChecksum, err: fmt.Errorf("body %s %x, expected %s", c.Algorithm, sum, strings.ToLower(c.Sum))}
+	}
+	return nil
+}
+
+// WithChecksum hashes every response body, once decompressed, and fails the
+// requests whose hash is not c. Targets can expect their own checksum.
+func (s *Stress) WithChecksum(c Checksum) *Stress {
+	s.Checksum = &c
+	return s
+}
+
+// checksum returns the checksum spec's responses are expected to have, or
+// nil.
+func (s *Stress) checksum(spec requestSpec) *Checksum {
+	if spec.Checksum != nil {
+		return spec.Checksum
+	}
+	return s.Checksum
+}
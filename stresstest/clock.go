@@ -0,0 +1,44 @@
+package stresstest
+
+import (
+	"net/http"
+	"time"
+)
+
+// Clock tells the time latencies and the length of a run are measured with.
+type Clock interface {
+	Now() time.Time
+}
+
+// WithClock measures latencies and the length of the run with c instead of
+// the system clock, so tests of code built on the package get exact numbers:
+// a RoundTripper given to WithTransport can move a fake clock forward to
+// simulate a slow server. Sleeps, timeouts and the schedules of the rate
+// limiter, the open model and the stages still follow the real time, as do
+// the phase timings.
+func (s *Stress) WithClock(c Clock) *Stress {
+	s.Clock = c
+	return s
+}
+
+func (s *Stress) now() time.Time {
+	if s.Clock == nil {
+		return time.Now()
+	}
+	return s.Clock.Now()
+}
+
+func (s *Stress) since(t time.Time) time.Duration {
+	return s.now().Sub(t)
+}
+
+// WithTransport sends the HTTP requests with rt instead of a transport built
+// from the protocol, TLS, proxy, DNS and connection settings, which are then
+// ignored. An http.RoundTripper that answers by itself simulates a server,
+// its latencies and its failures without the network. The WebSocket,
+// slowloris and connection flood modes open their own connections and don't
+// use it.
+func (s *Stress) WithTransport(rt http.RoundTripper) *Stress {
+	s.Transport = rt
+	return s
+}
@@ -0,0 +1,275 @@
+package stresstest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when Advance is called.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// fakeTransport answers every request itself. The nth request, counting from
+// 1, gets the status returned by status(n), or the error returned by err(n)
+// when it is not nil. It moves clock forward by latency when there is one,
+// or sleeps for delay, and tracks how many requests are in flight at once.
+type fakeTransport struct {
+	clock   *fakeClock
+	latency time.Duration
+	delay   time.Duration
+	status  func(n int) int
+	err     func(n int) error
+
+	calls    atomic.Int64
+	inFlight atomic.Int64
+	peak     atomic.Int64
+}
+
+func (t *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	n := int(t.calls.Add(1))
+	current := t.inFlight.Add(1)
+	defer t.inFlight.Add(-1)
+	for {
+		peak := t.peak.Load()
+		if current <= peak || t.peak.CompareAndSwap(peak, current) {
+			break
+		}
+	}
+
+	if t.clock != nil {
+		t.clock.Advance(t.latency)
+	}
+	if t.delay > 0 {
+		time.Sleep(t.delay)
+	}
+	if t.err != nil {
+		if err := t.err(n); err != nil {
+			return nil, err
+		}
+	}
+	status := http.StatusOK
+	if t.status != nil {
+		status = t.status(n)
+	}
+	return &http.Response{
+		Status:     http.StatusText(status),
+		StatusCode: status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("ok")),
+		Request:    req,
+	}, nil
+}
+
+func TestRunMeasuresLatencyWithClock(t *testing.T) {
+	clock := newFakeClock()
+	s := New("http://stress.test/", WithConcurrency(1), WithRequests(10))
+	s.WithTransport(&fakeTransport{clock: clock, latency: 20 * time.Millisecond})
+	s.WithClock(clock)
+
+	report, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Requests != 10 || report.Succeeded != 10 || report.Failed != 0 || report.TimedOut != 0 {
+		t.Errorf("requests %d, succeeded %d, failed %d, timed out %d, want 10, 10, 0, 0", report.Requests, report.Succeeded, report.Failed, report.TimedOut)
+	}
+	for name, got := range map[string]float64{"p50": report.P50, "p90": report.P90, "p95": report.P95, "p99": report.P99} {
+		if got != 20 {
+			t.Errorf("%s = %v ms, want 20", name, got)
+		}
+	}
+	if report.FastestTime != 20 || report.SlowestTime != 20 {
+		t.Errorf("fastest %d ms, slowest %d ms, want 20 and 20", report.FastestTime, report.SlowestTime)
+	}
+	if report.StdDev != 0 {
+		t.Errorf("std dev = %v, want 0", report.StdDev)
+	}
+	if report.TotalTime != 200 {
+		t.Errorf("total time = %v ms, want 200", report.TotalTime)
+	}
+	if report.AchievedRate != 50 {
+		t.Errorf("achieved rate = %v, want 50", report.AchievedRate)
+	}
+	if report.StatusRequests[http.StatusOK] != 10 {
+		t.Errorf("status 200 = %d, want 10", report.StatusRequests[http.StatusOK])
+	}
+}
+
+func TestRunReportsFrozenClockAsJSON(t *testing.T) {
+	s := New("http://stress.test/", WithConcurrency(2), WithRequests(5))
+	s.WithTransport(&fakeTransport{})
+	s.WithClock(newFakeClock())
+
+	report, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.AchievedRate != 0 {
+		t.Errorf("achieved rate = %v without elapsed time, want 0", report.AchievedRate)
+	}
+	if _, err := json.Marshal(report); err != nil {
+		t.Errorf("marshal report: %v", err)
+	}
+}
+
+func TestRunCountsStatusesAndFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport *fakeTransport
+		requests  int
+		succeeded int
+		failed    int
+		statuses  MapStatusRequests
+	}{
+		{
+			name: "server errors",
+			transport: &fakeTransport{status: func(n int) int {
+				if n%4 == 0 {
+					return http.StatusInternalServerError
+				}
+				return http.StatusOK
+			}},
+			requests:  12,
+			succeeded: 9,
+			failed:    3,
+			statuses:  MapStatusRequests{200: 9, 500: 3},
+		},
+		{
+			name:      "not found",
+			transport: &fakeTransport{status: func(int) int { return http.StatusNotFound }},
+			requests:  5,
+			failed:    5,
+			statuses:  MapStatusRequests{404: 5},
+		},
+		{
+			name: "transport errors",
+			transport: &fakeTransport{err: func(n int) error {
+				if n%5 == 0 {
+					return errors.New("connection lost")
+				}
+				return nil
+			}},
+			requests:  10,
+			succeeded: 8,
+			failed:    2,
+			statuses:  MapStatusRequests{200: 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New("http://stress.test/", WithConcurrency(1), WithRequests(tt.requests))
+			s.WithTransport(tt.transport)
+
+			report, err := s.Run(context.Background())
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if report.Requests != tt.requests || report.Succeeded != tt.succeeded || report.Failed != tt.failed {
+				t.Errorf("requests %d, succeeded %d, failed %d, want %d, %d, %d", report.Requests, report.Succeeded, report.Failed, tt.requests, tt.succeeded, tt.failed)
+			}
+			if len(report.StatusRequests) != len(tt.statuses) {
+				t.Errorf("statuses = %v, want %v", report.StatusRequests, tt.statuses)
+			}
+			for status, count := range tt.statuses {
+				if report.StatusRequests[status] != count {
+					t.Errorf("status %d = %d, want %d", status, report.StatusRequests[status], count)
+				}
+			}
+		})
+	}
+}
+
+func TestRunAgainstServer(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1)%5 == 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "hello")
+	}))
+	defer server.Close()
+
+	s := New(server.URL, WithConcurrency(4), WithRequests(20))
+	report, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Requests != 20 || report.Succeeded != 16 || report.Failed != 4 {
+		t.Errorf("requests %d, succeeded %d, failed %d, want 20, 16, 4", report.Requests, report.Succeeded, report.Failed)
+	}
+	if report.StatusRequests[http.StatusOK] != 16 || report.StatusRequests[http.StatusServiceUnavailable] != 4 {
+		t.Errorf("statuses = %v, want 16 x 200 and 4 x 503", report.StatusRequests)
+	}
+	if report.P50 <= 0 || report.SlowestTime < report.FastestTime {
+		t.Errorf("p50 %v ms, fastest %d ms, slowest %d ms", report.P50, report.FastestTime, report.SlowestTime)
+	}
+}
+
+func TestRunTimesOutSlowServer(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	s := New(server.URL, WithConcurrency(2), WithRequests(4), WithTimeout(50*time.Millisecond))
+	report, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Requests != 4 || report.TimedOut != 4 || report.Succeeded != 0 || report.Failed != 0 {
+		t.Errorf("requests %d, timed out %d, succeeded %d, failed %d, want 4, 4, 0, 0", report.Requests, report.TimedOut, report.Succeeded, report.Failed)
+	}
+	if report.ErrorBreakdown[ErrorKindTimeout] == nil || report.ErrorBreakdown[ErrorKindTimeout].Count != 4 {
+		t.Errorf("timeout errors = %+v, want 4", report.ErrorBreakdown[ErrorKindTimeout])
+	}
+}
+
+func TestRunEndingDuringExcludedRampUp(t *testing.T) {
+	s := New("http://stress.test/", WithConcurrency(1), WithRequests(3))
+	s.WithTransport(&fakeTransport{})
+	s.WithRampUp(time.Hour, true)
+
+	report, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Requests != 0 || report.TotalTime != 0 || report.AverageTime != 0 {
+		t.Errorf("requests %d, total time %v ms, average %v ms, want 0, 0, 0", report.Requests, report.TotalTime, report.AverageTime)
+	}
+}
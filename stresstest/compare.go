@@ -0,0 +1,154 @@
+package stresstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// RegressionThresholds are the changes from a base report that count as
+// regressions. Zero values are not checked.
+type RegressionThresholds struct {
+	// MaxLatencyIncrease is the highest acceptable increase of each latency
+	// percentile, in percent of the base value.
+	MaxLatencyIncrease float64
+	// MaxErrorRateIncrease is the highest acceptable increase of the error
+	// rate, in percentage points.
+	MaxErrorRateIncrease float64
+	// MaxRateDecrease is the highest acceptable drop of the achieved rate, in
+	// percent of the base value.
+	MaxRateDecrease float64
+}
+
+type metricKind int
+
+const (
+	metricOther metricKind = iota
+	metricLatency
+	metricErrorRate
+	metricRate
+)
+
+// MetricDelta is how one metric changed between two reports. Percent is the
+// change relative to Base, or 0 when Base is 0.
+type MetricDelta struct {
+	Name       string  `json:"name"`
+	Base       float64 `json:"base"`
+	New        float64 `json:"new"`
+	Delta      float64 `json:"delta"`
+	Percent    float64 `json:"percent"`
+	Regression bool    `json:"regression"`
+	kind       metricKind
+}
+
+// Comparison is the difference between a base report and a new one, such as
+// runs from before and after a deployment.
+type Comparison struct {
+	Metrics     []MetricDelta `json:"metrics"`
+	Regressions []string      `json:"regressions,omitempty"`
+}
+
+// RegressionError lists the metrics that regressed beyond the thresholds.
+type RegressionError struct {
+	Regressions []string
+}
+
+func (e *RegressionError) Error() string {
+	return "regressions found: " + strings.Join(e.Regressions, "; ")
+}
+
+// Compare diffs the latency percentiles, error rate and throughput of two
+// reports. Evaluate then checks the changes against regression thresholds.
+func Compare(base *StressReport, next *StressReport) *Comparison {
+	metric := func(name string, kind metricKind, base float64, next float64) MetricDelta {
+		m := MetricDelta{Name: name, Base: base, New: next, Delta: next - base, kind: kind}
+		if base != 0 {
+			m.Percent = m.Delta / base * 100
+		}
+		return m
+	}
+
+	return &Comparison{Metrics: []MetricDelta{
+		metric("Requests", metricOther, float64(base.Requests), float64(next.Requests)),
+		metric("ErrorRate", metricErrorRate, errorRate(base), errorRate(next)),
+		metric("AverageTime", metricOther, base.AverageTime, next.AverageTime),
+		metric("P50", metricLatency, base.P50, next.P50),
+		metric("P90", metricLatency, base.P90, next.P90),
+		metric("P95", metricLatency, base.P95, next.P95),
+		metric("P99", metricLatency, base.P99, next.P99),
+		metric("AchievedRate", metricRate, base.AchievedRate, next.AchievedRate),
+	}}
+}
+
+// Evaluate marks the metrics that changed beyond t, records them in the
+// comparison and returns them as a *RegressionError, or nil when there are
+// none.
+func (c *Comparison) Evaluate(t RegressionThresholds) error {
+	c.Regressions = nil
+	for i := range c.Metrics {
+		m := &c.Metrics[i]
+		m.Regression = false
+
+		switch {
+		case m.kind == metricLatency && t.MaxLatencyIncrease > 0 && m.Base > 0 && m.Percent > t.MaxLatencyIncrease:
+			c.Regressions = append(c.Regressions, fmt.Sprintf("%s up %.1f%% (%.2f ms to %.2f ms), above %.1f%%", m.Name, m.Percent, m.Base, m.New, t.MaxLatencyIncrease))
+		case m.kind == metricErrorRate && t.MaxErrorRateIncrease > 0 && m.Delta > t.MaxErrorRateIncrease:
+			c.Regressions = append(c.Regressions, fmt.Sprintf("error rate up %.2f points (%.2f%% to %.2f%%), above %.2f", m.Delta, m.Base, m.New, t.MaxErrorRateIncrease))
+		case m.kind == metricRate && t.MaxRateDecrease > 0 && m.Base > 0 && -m.Percent > t.MaxRateDecrease:
+			c.Regressions = append(c.Regressions, fmt.Sprintf("rate down %.1f%% (%.2f req/s to %.2f req/s), above %.1f%%", -m.Percent, m.Base, m.New, t.MaxRateDecrease))
+		default:
+			continue
+		}
+		m.Regression = true
+	}
+
+	if len(c.Regressions) == 0 {
+		return nil
+	}
+	return &RegressionError{Regressions: c.Regressions}
+}
+
+// JSON returns the comparison encoded as indented JSON.
+func (c *Comparison) JSON() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
+
+// WriteText writes the comparison as a table, flagging regressed metrics.
+func (c *Comparison) WriteText(w io.Writer) {
+	fmt.Fprintln(w, "--- Comparison ---")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Metric\tBase\tNew\tDelta\t")
+	for _, m := range c.Metrics {
+		delta := fmt.Sprintf("%+.2f", m.Delta)
+		if m.Base != 0 {
+			delta += fmt.Sprintf(" (%+.1f %%)", m.Percent)
+		}
+		flag := ""
+		if m.Regression {
+			flag = "REGRESSION"
+		}
+		fmt.Fprintf(tw, "%s%s\t%.2f\t%.2f\t%s\t%s\n", m.Name, m.unit(), m.Base, m.New, delta, flag)
+	}
+	tw.Flush()
+
+	if len(c.Regressions) > 0 {
+		fmt.Fprintln(w, "--- Regressions ---")
+		for _, regression := range c.Regressions {
+			fmt.Fprintln(w, regression)
+		}
+	}
+}
+
+func (m MetricDelta) unit() string {
+	switch {
+	case m.kind == metricLatency, m.Name == "AverageTime":
+		return " (ms)"
+	case m.kind == metricErrorRate:
+		return " (%)"
+	case m.kind == metricRate:
+		return " (req/s)"
+	}
+	return ""
+}
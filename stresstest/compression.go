@@ -0,0 +1,167 @@
+package stresstest
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// WithCompression sends an Accept-Encoding header listing encodings, such as
+// "gzip" and "br", on every request. Compressed responses are decoded by the
+// test itself (gzip, deflate and br are supported), so their size on the wire
+// and once decoded are both reported. BytesReceived counts the bytes on the
+// wire.
+func (s *Stress) WithCompression(encodings ...string) *Stress {
+	s.AcceptEncoding = encodings
+	return s
+}
+
+// WithDisableDecompression leaves compressed response bodies as they arrived,
+// which saves the CPU spent decoding them. Without WithCompression it also
+// stops net/http from asking for gzip on its own, so responses come
+// uncompressed unless a header asks otherwise.
+func (s *Stress) WithDisableDecompression(disabled bool) *Stress {
+	s.DisableDecompression = disabled
+	return s
+}
+
+// CompressionStats compares the size of the compressed responses on the wire
+// with their decoded size. It is only recorded with WithCompression or
+// WithDisableDecompression.
+type CompressionStats struct {
+	Compressed   int            `json:"compressed"`
+	Uncompressed int            `json:"uncompressed"`
+	Encodings    map[string]int `json:"encodings,omitempty"`
+	// CompressedBytes is the size of the compressed bodies on the wire and
+	// DecodedBytes their size once decoded, which is not known when
+	// decompression is disabled.
+	CompressedBytes int64 `json:"compressed_bytes"`
+	DecodedBytes    int64 `json:"decoded_bytes,omitempty"`
+	// Ratio is DecodedBytes divided by CompressedBytes and Saved the share of
+	// the decoded bytes compression did not send, in percent.
+	Ratio float64 `json:"ratio,omitempty"`
+	Saved float64 `json:"saved_percent,omitempty"`
+}
+
+// bodySize is the size of a response body on the wire and once decoded.
+type bodySize struct {
+	wire     int64
+	decoded  int64
+	encoding string // Content-Encoding of the body, if it was compressed
+	raw      bool   // the compressed body was not decoded
+	sum      []byte // hash of the body, when a checksum is expected
+}
+
+// compressionEnabled reports whether compression is recorded.
+func (s *Stress) compressionEnabled() bool {
+	return len(s.AcceptEncoding) > 0 || s.DisableDecompression
+}
+
+// decodeBody returns a reader of the decoded body of res, reading from body,
+// and whether it decodes it. Bodies are decoded here rather than by net/http,
+// which would hide their size on the wire; unknown encodings are read as they
+// are.
+func (s *Stress) decodeBody(res *http.Response, body io.Reader) (io.Reader, bool, error) {
+	if len(s.AcceptEncoding) == 0 || s.DisableDecompression {
+		return body, false, nil
+	}
+	var r io.Reader
+	var err error
+	switch contentEncoding(res) {
+	case "gzip", "x-gzip":
+		r, err = gzip.NewReader(body)
+	case "deflate":
+		// HTTP deflate is the zlib format.
+		r, err = zlib.NewReader(body)
+	case "br":
+		r = brotli.NewReader(body)
+	default:
+		return body, false, nil
+	}
+	return r, true, err
+}
+
+func contentEncoding(res *http.Response) string {
+	encoding := strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding")))
+	if encoding == "identity" {
+		return ""
+	}
+	return encoding
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *CompressionStats) add(size bodySize) {
+	if size.encoding == "" {
+		c.Uncompressed++
+		return
+	}
+	c.Compressed++
+	if c.Encodings == nil {
+		c.Encodings = make(map[string]int)
+	}
+	c.Encodings[size.encoding]++
+	c.CompressedBytes += size.wire
+	if !size.raw {
+		c.DecodedBytes += size.decoded
+	}
+}
+
+func (c *CompressionStats) merge(other CompressionStats) {
+	c.Compressed += other.Compressed
+	c.Uncompressed += other.Uncompressed
+	for encoding, responses := range other.Encodings {
+		if c.Encodings == nil {
+			c.Encodings = make(map[string]int)
+		}
+		c.Encodings[encoding] += responses
+	}
+	c.CompressedBytes += other.CompressedBytes
+	c.DecodedBytes += other.DecodedBytes
+}
+
+func (c *CompressionStats) finalize() {
+	if c.CompressedBytes > 0 && c.DecodedBytes > 0 {
+		c.Ratio = float64(c.DecodedBytes) / float64(c.CompressedBytes)
+		c.Saved = 100 * (1 - float64(c.CompressedBytes)/float64(c.DecodedBytes))
+	}
+}
+
+func (c *CompressionStats) writeText(w io.Writer) {
+	if c.Compressed+c.Uncompressed == 0 {
+		return
+	}
+	fmt.Fprintln(w, "--- Compression ---")
+	fmt.Fprintln(w, "Compressed:", c.Compressed, "responses")
+	fmt.Fprintln(w, "Uncompressed:", c.Uncompressed, "responses")
+	encodings := make([]string, 0, len(c.Encodings))
+	for encoding := range c.Encodings {
+		encodings = append(encodings, encoding)
+	}
+	sort.Strings(encodings)
+	for _, encoding := range encodings {
+		fmt.Fprintln(w, encoding+":", c.Encodings[encoding], "responses")
+	}
+	fmt.Fprintln(w, "CompressedBytes:", c.CompressedBytes, "bytes")
+	if c.DecodedBytes > 0 {
+		fmt.Fprintln(w, "DecodedBytes:", c.DecodedBytes, "bytes")
+		fmt.Fprintf(w, "Ratio: %.2f\n", c.Ratio)
+		fmt.Fprintf(w, "Saved: %.1f %%\n", c.Saved)
+	}
+}
@@ -0,0 +1,308 @@
+package stresstest
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ConnectFloodConfig configures a connection flood, see WithConnectFlood.
+type ConnectFloodConfig struct {
+	// TLS runs the TLS handshake on every connection, which needs an https
+	// URL. Without it only the TCP connection is opened.
+	TLS bool
+	// Resume keeps the TLS sessions in a cache shared by the workers, so
+	// connections resume one when the server issued a ticket and the report
+	// compares full and resumed handshakes. It is the cache set with
+	// WithTLSSessionCache when there is one, which resumes sessions even
+	// without Resume. Otherwise every handshake is a full one.
+	Resume bool
+}
+
+// ticketWait is how long a full TLS 1.3 handshake of a resuming flood waits
+// for the session tickets, which servers send after the handshake.
+const ticketWait = 100 * time.Millisecond
+
+// WithConnectFlood turns the run into a flood of connections to the target's
+// host and port, for its listener backlog and TLS termination capacity: no
+// HTTP is sent, each connection is closed as soon as it is open, or its TLS
+// handshake done, and Concurrency workers open Requests of them in total, or
+// keep opening them for Duration, at RatePerSecond when set. Only the
+// connection flood section of the report is filled in.
+func (s *Stress) WithConnectFlood(cfg ConnectFloodConfig) *Stress {
+	s.ConnectFlood = &cfg
+	return s
+}
+
+// ConnectFloodStats describes the connections of a connection flood. Times
+// are in milliseconds.
+type ConnectFloodStats struct {
+	// Connections counts the TCP connections opened, HandshakeFailures those
+	// whose TLS handshake then failed.
+	Connections          int     `json:"connections"`
+	ConnectFailures      int     `json:"connect_failures"`
+	HandshakeFailures    int     `json:"handshake_failures"`
+	ConnectionsPerSecond float64 `json:"connections_per_second"`
+	ConnectAverage       float64 `json:"connect_average"`
+	ConnectP50           float64 `json:"connect_p50"`
+	ConnectP95           float64 `json:"connect_p95"`
+	ConnectP99           float64 `json:"connect_p99"`
+	// Handshakes counts the TLS handshakes done, ResumedHandshakes those that
+	// resumed a session. The Handshake latencies are those of the full
+	// handshakes, the Resumed ones those of the resumed handshakes.
+	Handshakes          int     `json:"handshakes,omitempty"`
+	ResumedHandshakes   int     `json:"resumed_handshakes,omitempty"`
+	HandshakesPerSecond float64 `json:"handshakes_per_second,omitempty"`
+	HandshakeAverage    float64 `json:"handshake_average,omitempty"`
+	HandshakeP50        float64 `json:"handshake_p50,omitempty"`
+	HandshakeP95        float64 `json:"handshake_p95,omitempty"`
+	HandshakeP99        float64 `json:"handshake_p99,omitempty"`
+	ResumedAverage      float64 `json:"resumed_average,omitempty"`
+	ResumedP50          float64 `json:"resumed_p50,omitempty"`
+	ResumedP95          float64 `json:"resumed_p95,omitempty"`
+	ResumedP99          float64 `json:"resumed_p99,omitempty"`
+	// Negotiated counts the handshakes per TLS version and cipher suite, to
+	// check what the server picked among those offered.
+	Negotiated         map[string]int `json:"negotiated,omitempty"`
+	connectLatencies   []time.Duration
+	handshakeLatencies []time.Duration
+	resumedLatencies   []time.Duration
+}
+
+func (f *ConnectFloodStats) finalize(elapsed time.Duration) {
+	if elapsed > 0 {
+		f.ConnectionsPerSecond = float64(f.Connections) / elapsed.Seconds()
+		f.HandshakesPerSecond = float64(f.Handshakes) / elapsed.Seconds()
+	}
+	f.ConnectAverage, f.ConnectP50, f.ConnectP95, f.ConnectP99 = latencySummary(f.connectLatencies)
+	f.HandshakeAverage, f.HandshakeP50, f.HandshakeP95, f.HandshakeP99 = latencySummary(f.handshakeLatencies)
+	f.ResumedAverage, f.ResumedP50, f.ResumedP95, f.ResumedP99 = latencySummary(f.resumedLatencies)
+}
+
+// latencySummary returns the average, p50, p95 and p99 of latencies, in
+// milliseconds.
+func latencySummary(latencies []time.Duration) (float64, float64, float64, float64) {
+	if len(latencies) == 0 {
+		return 0, 0, 0, 0
+	}
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	var sum time.Duration
+	for _, latency := range sorted {
+		sum += latency
+	}
+	return milliseconds(sum / time.Duration(len(sorted))), percentile(sorted, 50), percentile(sorted, 95), percentile(sorted, 99)
+}
+
+func (f *ConnectFloodStats) writeText(w io.Writer) {
+	fmt.Fprintln(w, "--- Connection flood ---")
+	fmt.Fprintln(w, "Connections:", f.Connections)
+	fmt.Fprintln(w, "ConnectFailures:", f.ConnectFailures)
+	fmt.Fprintln(w, "ConnectionsPerSecond:", f.ConnectionsPerSecond)
+	fmt.Fprintln(w, "ConnectAverage:", f.ConnectAverage, "ms")
+	fmt.Fprintln(w, "ConnectP50:", f.ConnectP50, "ms")
+	fmt.Fprintln(w, "ConnectP95:", f.ConnectP95, "ms")
+	fmt.Fprintln(w, "ConnectP99:", f.ConnectP99, "ms")
+	if f.HandshakeFailures == 0 && f.Handshakes == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Handshakes:", f.Handshakes)
+	fmt.Fprintln(w, "HandshakeFailures:", f.HandshakeFailures)
+	fmt.Fprintln(w, "HandshakesPerSecond:", f.HandshakesPerSecond)
+	if f.Handshakes > f.ResumedHandshakes {
+		fmt.Fprintln(w, "FullHandshakes:", f.Handshakes-f.ResumedHandshakes)
+		fmt.Fprintln(w, "HandshakeAverage:", f.HandshakeAverage, "ms")
+		fmt.Fprintln(w, "HandshakeP50:", f.HandshakeP50, "ms")
+		fmt.Fprintln(w, "HandshakeP95:", f.HandshakeP95, "ms")
+		fmt.Fprintln(w, "HandshakeP99:", f.HandshakeP99, "ms")
+	}
+	if f.ResumedHandshakes > 0 {
+		fmt.Fprintln(w, "ResumedHandshakes:", f.ResumedHandshakes)
+		fmt.Fprintln(w, "ResumedAverage:", f.ResumedAverage, "ms")
+		fmt.Fprintln(w, "ResumedP50:", f.ResumedP50, "ms")
+		fmt.Fprintln(w, "ResumedP95:", f.ResumedP95, "ms")
+		fmt.Fprintln(w, "ResumedP99:", f.ResumedP99, "ms")
+	}
+	negotiated := make([]string, 0, len(f.Negotiated))
+	for name := range f.Negotiated {
+		negotiated = append(negotiated, name)
+	}
+	slices.Sort(negotiated)
+	for _, name := range negotiated {
+		fmt.Fprintln(w, name+":", f.Negotiated[name], "handshakes")
+	}
+}
+
+// runConnectFlood opens and closes connections from every worker.
+func (s *Stress) runConnectFlood(ctx context.Context, wg *sync.WaitGroup) {
+	s.Report.ConnectFlood = &ConnectFloodStats{}
+
+	// The URL was checked by Validate.
+	target, _ := url.Parse(s.URL)
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		s.lastErr = err
+		return
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = target.Hostname()
+	}
+	if s.ConnectFlood.Resume && tlsConfig.ClientSessionCache == nil {
+		tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+	localAddr, err := s.localAddr()
+	if err != nil {
+		s.lastErr = err
+		return
+	}
+	dial, err := s.newDialer(&net.Dialer{Timeout: s.connectTimeout(), LocalAddr: localAddr})
+	if err != nil {
+		s.lastErr = err
+		return
+	}
+
+	// Without a duration the run opens Requests connections in total.
+	var slots chan struct{}
+	if s.Duration <= 0 {
+		if s.Requests <= 0 {
+			return
+		}
+		slots = newSlots(s.Requests)
+	}
+
+	for i := 0; i < s.Concurrency; i++ {
+		wg.Add(1)
+		i := i
+
+		go func() {
+			defer wg.Done()
+			if !sleepContext(ctx, s.rampUpDelay(i)) {
+				return
+			}
+			for ctx.Err() == nil {
+				if slots != nil {
+					if _, ok := <-slots; !ok {
+						return
+					}
+				}
+				if s.limiter != nil {
+					if err := s.limiter.Wait(ctx); err != nil {
+						return
+					}
+				}
+				s.floodConnection(ctx, dial, tlsConfig, hostPort(target))
+			}
+		}()
+	}
+}
+
+// floodConnection opens one connection to addr, runs the TLS handshake when
+// the flood asks for it, and closes the connection.
+func (s *Stress) floodConnection(ctx context.Context, dial dialFunc, tlsConfig *tls.Config, addr string) {
+	stats := s.Report.ConnectFlood
+	fail := func(err error) {
+		s.Report.addError(classifyError(err), err)
+		s.lastErr = err
+	}
+
+	start := time.Now()
+	conn, err := dial(ctx, "tcp", addr)
+	connect := time.Since(start)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		s.mu.Lock()
+		stats.ConnectFailures++
+		fail(err)
+		s.mu.Unlock()
+		return
+	}
+	defer conn.Close()
+
+	var handshake time.Duration
+	var handshakeErr error
+	var state tls.ConnectionState
+	if s.ConnectFlood.TLS {
+		handshakeCtx := ctx
+		if s.Timeouts.TLSHandshake > 0 {
+			var cancel context.CancelFunc
+			handshakeCtx, cancel = context.WithTimeout(ctx, s.Timeouts.TLSHandshake)
+			defer cancel()
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		start := time.Now()
+		handshakeErr = tlsConn.HandshakeContext(handshakeCtx)
+		handshake = time.Since(start)
+		state = tlsConn.ConnectionState()
+		if handshakeErr == nil && tlsConfig.ClientSessionCache != nil && !state.DidResume && state.Version == tls.VersionTLS13 {
+			// Reading processes the tickets; nothing else is expected.
+			tlsConn.SetReadDeadline(time.Now().Add(ticketWait))
+			tlsConn.Read(make([]byte, 1))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats.Connections++
+	stats.connectLatencies = append(stats.connectLatencies, connect)
+	if handshakeErr != nil {
+		if ctx.Err() == nil {
+			stats.HandshakeFailures++
+			fail(handshakeErr)
+		}
+		return
+	}
+	if !s.ConnectFlood.TLS {
+		return
+	}
+	stats.Handshakes++
+	if state.DidResume {
+		stats.ResumedHandshakes++
+		stats.resumedLatencies = append(stats.resumedLatencies, handshake)
+	} else {
+		stats.handshakeLatencies = append(stats.handshakeLatencies, handshake)
+	}
+	if stats.Negotiated == nil {
+		stats.Negotiated = make(map[string]int)
+	}
+	stats.Negotiated[tls.VersionName(state.Version)+" "+tls.CipherSuiteName(state.CipherSuite)]++
+}
+
+// hostPort returns the host:port to connect to for target, with the default
+// port of its scheme when it has none.
+func hostPort(target *url.URL) string {
+	if target.Port() != "" {
+		return target.Host
+	}
+	port := "80"
+	if target.Scheme == "https" || target.Scheme == "wss" {
+		port = "443"
+	}
+	return net.JoinHostPort(target.Hostname(), port)
+}
+
+// validateConnectFlood checks the target of a connection flood.
+func (s *Stress) validateConnectFlood() error {
+	target, err := url.Parse(s.URL)
+	if err != nil {
+		return err
+	}
+	if target.Hostname() == "" {
+		return fmt.Errorf("connection flood needs a URL with a host, not %q", s.URL)
+	}
+	if s.ConnectFlood.Resume && !s.ConnectFlood.TLS {
+		return errors.New("resuming TLS sessions needs a TLS connection flood")
+	}
+	if s.ConnectFlood.TLS && target.Scheme != "https" {
+		return fmt.Errorf("TLS connection flood needs an https URL, not %q", s.URL)
+	}
+	return nil
+}
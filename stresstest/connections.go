@@ -0,0 +1,122 @@
+package stresstest
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ConnectionStats tells how well keep-alive worked: how many requests opened
+// a new connection and how many reused one from the pool, as reported by the
+// transport. HTTP/2 requests multiplexed on an open connection count as
+// reused.
+type ConnectionStats struct {
+	New    int `json:"new"`
+	Reused int `json:"reused"`
+	// ReuseRate is the share of the requests that reused a connection, in
+	// percent.
+	ReuseRate float64 `json:"reuse_rate"`
+	// Remotes counts the new connections per remote address, to see how a
+	// load balancer spreads them between its addresses.
+	Remotes map[string]int `json:"remotes,omitempty"`
+	// Handshakes counts the TLS and QUIC handshakes of the new connections,
+	// Resumed those that resumed a session, and ResumptionRate is the share
+	// of them that did, in percent. Sessions are only resumed with a session
+	// cache.
+	Handshakes     int     `json:"tls_handshakes,omitempty"`
+	Resumed        int     `json:"resumed_handshakes,omitempty"`
+	ResumptionRate float64 `json:"resumption_rate,omitempty"`
+}
+
+func (c *ConnectionStats) add(t requestTimings) {
+	if t.TLS > 0 || t.QUIC > 0 {
+		c.Handshakes++
+		if t.Resumed {
+			c.Resumed++
+		}
+	}
+	if !t.GotConn {
+		return
+	}
+	if t.Reused {
+		c.Reused++
+		return
+	}
+	c.New++
+	if t.Remote != "" {
+		if c.Remotes == nil {
+			c.Remotes = make(map[string]int)
+		}
+		c.Remotes[t.Remote]++
+	}
+}
+
+func (c *ConnectionStats) merge(other ConnectionStats) {
+	c.New += other.New
+	c.Reused += other.Reused
+	c.Handshakes += other.Handshakes
+	c.Resumed += other.Resumed
+	for remote, connections := range other.Remotes {
+		if c.Remotes == nil {
+			c.Remotes = make(map[string]int)
+		}
+		c.Remotes[remote] += connections
+	}
+}
+
+func (c *ConnectionStats) finalize() {
+	if total := c.New + c.Reused; total > 0 {
+		c.ReuseRate = float64(c.Reused) / float64(total) * 100
+	}
+	if c.Handshakes > 0 {
+		c.ResumptionRate = float64(c.Resumed) / float64(c.Handshakes) * 100
+	}
+}
+
+// writeText lists the remote addresses, when there are several, by number of
+// connections, most first.
+func (c *ConnectionStats) writeText(w io.Writer) {
+	if c.New+c.Reused == 0 {
+		return
+	}
+	fmt.Fprintln(w, "--- Connections ---")
+	fmt.Fprintln(w, "New:", c.New, "requests")
+	fmt.Fprintln(w, "Reused:", c.Reused, "requests")
+	fmt.Fprintln(w, "ReuseRate:", c.ReuseRate, "%")
+	if c.Handshakes > 0 {
+		fmt.Fprintln(w, "TLSHandshakes:", c.Handshakes)
+		fmt.Fprintln(w, "ResumedHandshakes:", c.Resumed)
+		fmt.Fprintln(w, "ResumptionRate:", c.ResumptionRate, "%")
+	}
+	if len(c.Remotes) < 2 {
+		return
+	}
+	remotes := make([]string, 0, len(c.Remotes))
+	for remote := range c.Remotes {
+		remotes = append(remotes, remote)
+	}
+	slices.SortFunc(remotes, func(a, b string) int {
+		if c.Remotes[a] != c.Remotes[b] {
+			return c.Remotes[b] - c.Remotes[a]
+		}
+		return strings.Compare(a, b)
+	})
+	for _, remote := range remotes {
+		fmt.Fprintln(w, remote+":", c.Remotes[remote], "connections")
+	}
+}
+
+// addNewConnection records a connection opened offset into the run, for the
+// connection churn of the time series.
+func (r *StressReport) addNewConnection(offset time.Duration) {
+	if offset < 0 {
+		offset = 0
+	}
+	second := int(offset / time.Second)
+	for len(r.timeline) <= second {
+		r.timeline = append(r.timeline, timelineBucket{})
+	}
+	r.timeline[second].NewConnections++
+}
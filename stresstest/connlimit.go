@@ -0,0 +1,57 @@
+package stresstest
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// WithMaxConnections caps how many connections the HTTP client keeps open at
+// once, idle ones included, whatever the concurrency or arrival rate. A
+// request that needs a new connection while n are open waits for one to
+// close, and the wait counts in its latency. With HTTP/1.1 this also caps the
+// requests in flight; HTTP/2 multiplexes them over the connections.
+func (s *Stress) WithMaxConnections(n int) *Stress {
+	s.MaxConnections = n
+	return s
+}
+
+// limitConnections wraps dial so that at most n of its connections are open
+// at once. closeIdle is called when the limit is reached, since idle
+// connections hold slots too and a host with requests waiting should be able
+// to take them over.
+func limitConnections(dial dialFunc, n int, closeIdle func()) dialFunc {
+	slots := make(chan struct{}, n)
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		select {
+		case slots <- struct{}{}:
+		default:
+			closeIdle()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			<-slots
+			return nil, err
+		}
+		return &slotConn{Conn: conn, release: func() { <-slots }}, nil
+	}
+}
+
+// slotConn gives its slot back when it is closed.
+type slotConn struct {
+	net.Conn
+	release func()
+	once    sync.Once
+}
+
+func (c *slotConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
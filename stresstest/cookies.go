@@ -0,0 +1,9 @@
+package stresstest
+
+// WithCookieJar gives every virtual user its own cookie jar, so
+// cookies set by one request, e.g. a login step of a scenario, are sent on
+// that user's following requests.
+func (s *Stress) WithCookieJar(enabled bool) *Stress {
+	s.CookieJar = enabled
+	return s
+}
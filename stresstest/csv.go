@@ -0,0 +1,72 @@
+package stresstest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// csvReporter streams one row per request to a file so raw results can be
+// analysed elsewhere without keeping them all in memory.
+type csvReporter struct {
+	mu   sync.Mutex
+	file *os.File
+	w    *csv.Writer
+}
+
+// NewCSVReporter creates the file at path and writes a row to it for every
+// request. The file is closed by Finalize.
+func NewCSVReporter(path string) (Reporter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := csv.NewWriter(file)
+	if err := w.Write([]string{"timestamp", "worker", "status", "latency_ms", "error", "request_id"}); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &csvReporter{file: file, w: w}, nil
+}
+
+func (c *csvReporter) Collect(sample Sample) {
+	status, errMsg := "", ""
+	if sample.Err != nil {
+		errMsg = sample.Err.Error()
+	} else {
+		status = strconv.Itoa(sample.Status)
+	}
+
+	record := []string{
+		sample.Start.Format(time.RFC3339Nano),
+		strconv.Itoa(sample.Worker),
+		status,
+		strconv.FormatFloat(milliseconds(sample.Latency), 'f', 3, 64),
+		errMsg,
+		sample.RequestID,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.w.Write(record)
+}
+
+func (c *csvReporter) Finalize(*StressReport) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		c.file.Close()
+		return fmt.Errorf("samples csv: %w", err)
+	}
+	if err := c.file.Close(); err != nil {
+		return fmt.Errorf("samples csv: %w", err)
+	}
+	return nil
+}
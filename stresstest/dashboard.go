@@ -0,0 +1,262 @@
+package stresstest
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const dashboardInterval = time.Second
+
+// sparkTicks are the bars of a sparkline, from lowest to highest.
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// WithDashboard replaces the progress line with a full screen dashboard that
+// refreshes in place while the test runs: the current rate, latency and rate
+// sparklines, the status codes, the errors and the progress of the run.
+// Pressing q or Ctrl+C stops the test as SIGINT would.
+func (s *Stress) WithDashboard(dashboard bool) *Stress {
+	s.Dashboard = dashboard
+	return s
+}
+
+// dashboardRow is a line of the status or error table.
+type dashboardRow struct {
+	label   string
+	count   int
+	example string
+}
+
+// dashboard holds what the screen shows, collected once per interval.
+type dashboard struct {
+	elapsed   time.Duration
+	requests  int
+	succeeded int
+	failed    int
+	timedOut  int
+	rates     []float64
+	latencies []float64
+	statuses  []dashboardRow
+	errors    []dashboardRow
+}
+
+// startDashboard draws the dashboard every second until the returned function
+// is called, which restores the terminal. When the screen cannot be set up it
+// falls back to the progress line.
+func (s *Stress) startDashboard() func() {
+	screen, err := tcell.NewScreen()
+	if err == nil {
+		err = screen.Init()
+	}
+	if err != nil {
+		fmt.Fprintln(s.stderr(), "Cannot start the dashboard, showing progress instead:", err)
+		return s.startProgress()
+	}
+	screen.HideCursor()
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	redraw := make(chan struct{}, 1)
+
+	go func() {
+		for {
+			switch ev := screen.PollEvent().(type) {
+			case nil:
+				return
+			case *tcell.EventResize:
+				screen.Sync()
+				select {
+				case redraw <- struct{}{}:
+				default:
+				}
+			case *tcell.EventKey:
+				if ev.Key() == tcell.KeyCtrlC || ev.Rune() == 'q' {
+					interrupt()
+				}
+			}
+		}
+	}()
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(dashboardInterval)
+		defer ticker.Stop()
+
+		start := time.Now()
+		lastRequests, lastCount := 0, 0
+		var lastSum time.Duration
+		var d dashboard
+		s.drawDashboard(screen, &d)
+		for {
+			select {
+			case <-done:
+				return
+			case <-redraw:
+				s.drawDashboard(screen, &d)
+				continue
+			case <-ticker.C:
+			}
+
+			s.mu.Lock()
+			d.elapsed = time.Since(start)
+			d.requests = s.Report.Requests
+			d.succeeded = s.Report.Succeeded
+			d.failed = s.Report.Failed
+			d.timedOut = s.Report.TimedOut
+			recent := s.Report.latencyCount - lastCount
+			sum := s.Report.latencySum - lastSum
+			lastCount, lastSum = s.Report.latencyCount, s.Report.latencySum
+			d.statuses = d.statuses[:0]
+			for status, requests := range s.Report.StatusRequests {
+				d.statuses = append(d.statuses, dashboardRow{label: fmt.Sprint(status), count: requests})
+			}
+			d.errors = d.errors[:0]
+			for kind, detail := range s.Report.ErrorBreakdown {
+				row := dashboardRow{label: string(kind), count: detail.Count}
+				if len(detail.Examples) > 0 {
+					row.example = detail.Examples[0]
+				}
+				d.errors = append(d.errors, row)
+			}
+			s.mu.Unlock()
+
+			var average float64
+			if recent > 0 {
+				average = milliseconds(sum / time.Duration(recent))
+			}
+			d.latencies = append(d.latencies, average)
+			d.rates = append(d.rates, float64(d.requests-lastRequests)/dashboardInterval.Seconds())
+			lastRequests = d.requests
+			s.drawDashboard(screen, &d)
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		screen.Fini()
+	}
+}
+
+func (s *Stress) drawDashboard(screen tcell.Screen, d *dashboard) {
+	screen.Clear()
+	width, height := screen.Size()
+	bold := tcell.StyleDefault.Bold(true)
+	dim := tcell.StyleDefault.Dim(true)
+	red := tcell.StyleDefault.Foreground(tcell.ColorRed)
+
+	y := 0
+	line := func(style tcell.Style, text string) {
+		if y < height-1 {
+			drawText(screen, 0, y, width, style, text)
+		}
+		y++
+	}
+
+	target := s.URL
+	switch {
+	case s.Scenario != nil:
+		target = "scenario"
+	case len(s.Targets) > 0:
+		target = fmt.Sprintf("%d targets", len(s.Targets))
+	}
+	line(bold, "golang-stress-test  "+target)
+	line(tcell.StyleDefault, fmt.Sprintf("Elapsed %s   Requests %d   Succeeded %d   Failed %d   Timed out %d",
+		d.elapsed.Round(time.Second), d.requests, d.succeeded, d.failed, d.timedOut))
+	if done, ok := s.dashboardProgress(d); ok {
+		barWidth := max(width-8, 10)
+		filled := min(int(done*float64(barWidth)), barWidth)
+		line(tcell.StyleDefault, "["+strings.Repeat("#", filled)+strings.Repeat(".", barWidth-filled)+fmt.Sprintf("] %3.0f%%", done*100))
+	}
+	y++
+
+	rate, latency := 0.0, 0.0
+	if n := len(d.rates); n > 0 {
+		rate, latency = d.rates[n-1], d.latencies[n-1]
+	}
+	line(bold, fmt.Sprintf("Rate %.1f req/s (peak %.1f)", rate, slices.Max(append([]float64{0}, d.rates...))))
+	line(tcell.StyleDefault, sparkline(d.rates, width))
+	line(bold, fmt.Sprintf("Average latency %.2f ms (peak %.2f)", latency, slices.Max(append([]float64{0}, d.latencies...))))
+	line(tcell.StyleDefault, sparkline(d.latencies, width))
+	y++
+
+	line(bold, "Status codes")
+	slices.SortFunc(d.statuses, func(a, b dashboardRow) int { return strings.Compare(a.label, b.label) })
+	for _, row := range d.statuses {
+		line(tcell.StyleDefault, fmt.Sprintf("  %-20s %d", row.label, row.count))
+	}
+	y++
+
+	line(bold, "Errors")
+	if len(d.errors) == 0 {
+		line(dim, "  none")
+	}
+	slices.SortFunc(d.errors, func(a, b dashboardRow) int {
+		if a.count != b.count {
+			return b.count - a.count
+		}
+		return strings.Compare(a.label, b.label)
+	})
+	for _, row := range d.errors {
+		line(red, fmt.Sprintf("  %-20s %d  %s", row.label, row.count, row.example))
+	}
+
+	drawText(screen, 0, height-1, width, dim, "q or Ctrl+C to stop")
+	screen.Show()
+}
+
+// dashboardProgress returns the completed fraction of a run bounded by a
+// number of requests or a duration.
+func (s *Stress) dashboardProgress(d *dashboard) (float64, bool) {
+	switch {
+	case s.Duration > 0:
+		return min(d.elapsed.Seconds()/s.Duration.Seconds(), 1), true
+	case s.Scenario == nil && len(s.Stages) == 0 && s.Requests > 0:
+		return min(float64(d.requests)/float64(s.Requests), 1), true
+	}
+	return 0, false
+}
+
+// sparkline draws the last values that fit in width, scaled to the highest
+// of them.
+func sparkline(values []float64, width int) string {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	peak := 0.0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		tick := 0
+		if peak > 0 {
+			tick = int(v / peak * float64(len(sparkTicks)-1))
+		}
+		b.WriteRune(sparkTicks[tick])
+	}
+	return b.String()
+}
+
+func drawText(screen tcell.Screen, x, y, width int, style tcell.Style, text string) {
+	for _, r := range text {
+		if x >= width {
+			return
+		}
+		screen.SetContent(x, y, r, nil, style)
+		x++
+	}
+}
+
+// interrupt stops the test the way Ctrl+C would outside the dashboard, which
+// puts the terminal in raw mode and so keeps the key from raising SIGINT.
+func interrupt() {
+	if p, err := os.FindProcess(os.Getpid()); err == nil {
+		p.Signal(os.Interrupt)
+	}
+}
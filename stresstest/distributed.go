@@ -0,0 +1,365 @@
+package stresstest
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Plan is the part of a stress test configuration that can be sent to agents.
+type Plan struct {
+	URL           string        `json:"url"`
+	Method        string        `json:"method"`
+	Concurrency   int           `json:"concurrency"`
+	Requests      int           `json:"requests"`
+	Timeout       time.Duration `json:"timeout"`
+	Timeouts      Timeouts      `json:"timeouts"`
+	Duration      time.Duration `json:"duration"`
+	RatePerSecond float64       `json:"rate_per_second"`
+	Headers       http.Header   `json:"headers"`
+	Body          string        `json:"body"`
+	ContentType   string        `json:"content_type"`
+	VerifyTls     bool          `json:"verify_tls"`
+	MaxSamples    int           `json:"max_samples"`
+	// HistogramBuckets are the bounds of the merged latency histogram.
+	HistogramBuckets []float64 `json:"histogram_buckets,omitempty"`
+}
+
+// agentResult is what an agent sends back. Latencies travel alongside the
+// report so the coordinator can compute exact percentiles and histograms over
+// all agents, and so do the counts behind the phase averages.
+type agentResult struct {
+	Report            *StressReport              `json:"report"`
+	Latencies         []time.Duration            `json:"latencies"`
+	EndpointLatencies map[string][]time.Duration `json:"endpoint_latencies,omitempty"`
+	PhaseCounts       phaseCounts                `json:"phase_counts"`
+	Error             string                     `json:"error,omitempty"`
+}
+
+func (p Plan) newStress() *Stress {
+	s := New(p.URL,
+		WithMethod(p.Method),
+		WithConcurrency(p.Concurrency),
+		WithRequests(p.Requests),
+		WithTimeout(p.Timeout),
+		WithVerifyTLS(p.VerifyTls),
+	)
+	s.WithDuration(p.Duration)
+	s.WithTimeouts(p.Timeouts)
+	s.WithMaxSamples(p.MaxSamples)
+	s.WithHistogramBuckets(p.HistogramBuckets...)
+	s.WithRatePerSecond(p.RatePerSecond)
+	s.WithContentType(p.ContentType)
+	for key, values := range p.Headers {
+		for _, value := range values {
+			s.WithHeader(key, value)
+		}
+	}
+	if p.Body != "" {
+		s.WithBody(BodyFromString(p.Body))
+	}
+	return s
+}
+
+// split divides the requests, concurrency and rate of the plan evenly between
+// n agents, so together they generate the load of the original plan. The first
+// agents get what doesn't divide evenly, and every agent gets at least one
+// worker.
+func (p Plan) split(n int) []Plan {
+	plans := make([]Plan, n)
+	for i := range plans {
+		plans[i] = p
+		plans[i].Requests = p.Requests / n
+		if i < p.Requests%n {
+			plans[i].Requests++
+		}
+		plans[i].Concurrency = p.Concurrency / n
+		if i < p.Concurrency%n {
+			plans[i].Concurrency++
+		}
+		plans[i].Concurrency = max(1, plans[i].Concurrency)
+		plans[i].RatePerSecond = p.RatePerSecond / float64(n)
+	}
+	return plans
+}
+
+// RunDistributed fans the plan out to the agents at the given addresses
+// (host:port or URLs) and merges their results into one report. The token is
+// the one the agents were started with.
+func RunDistributed(ctx context.Context, plan Plan, agents []string, token string) (*StressReport, error) {
+	if len(agents) == 0 {
+		return nil, errors.New("no agents given")
+	}
+	if token == "" {
+		return nil, errors.New("no agent token given")
+	}
+
+	plans := plan.split(len(agents))
+	results := make([]*agentResult, len(agents))
+	errs := make([]error, len(agents))
+
+	var wg sync.WaitGroup
+	for i, agent := range agents {
+		wg.Add(1)
+		i, agent := i, agent
+
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = runOnAgent(ctx, agent, plans[i], token)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("agent %s: %w", agent, errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	reports := make([]*StressReport, len(results))
+	for i, result := range results {
+		result.Report.latencies = result.Latencies
+		result.Report.timeline = timelineFromTimeSeries(result.Report.TimeSeries)
+		result.Report.Phases.counts = result.PhaseCounts
+		for label, latencies := range result.EndpointLatencies {
+			if endpoint, ok := result.Report.Endpoints[label]; ok {
+				endpoint.latencies = latencies
+			}
+		}
+		reports[i] = result.Report
+	}
+	report := mergeReports(plan.HistogramBuckets, reports...)
+	report.RequestedRate = plan.RatePerSecond
+	return report, nil
+}
+
+func runOnAgent(ctx context.Context, agent string, plan Plan, token string) (*agentResult, error) {
+	body, err := json.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.Contains(agent, "://") {
+		agent = "http://" + agent
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(agent, "/")+"/run", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var result agentResult
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode result (status %d): %w", res.StatusCode, err)
+	}
+	if result.Error != "" {
+		return nil, errors.New(result.Error)
+	}
+	if result.Report == nil {
+		return nil, fmt.Errorf("no report in response (status %d)", res.StatusCode)
+	}
+	return &result, nil
+}
+
+// MergeReports combines reports from separate runs into one, as if the
+// requests had all been made by a single run. The total time is that of the
+// longest run.
+func MergeReports(reports ...*StressReport) *StressReport {
+	return mergeReports(nil, reports...)
+}
+
+// mergeReports merges the reports, bucketing the latencies of the merged
+// histogram with bounds as computeHistogram does.
+func mergeReports(bounds []float64, reports ...*StressReport) *StressReport {
+	merged := NewStressReport()
+	var elapsed time.Duration
+	sampled := false
+
+	for _, r := range reports {
+		merged.Requests += r.Requests
+		merged.Failed += r.Failed
+		merged.Succeeded += r.Succeeded
+		merged.TimedOut += r.TimedOut
+		merged.WarmUpRequests += r.WarmUpRequests
+		merged.BytesReceived += r.BytesReceived
+		merged.HeaderBytesReceived += r.HeaderBytesReceived
+		merged.Retries += r.Retries
+		merged.SucceededAfterRetry += r.SucceededAfterRetry
+		merged.Cancelled = merged.Cancelled || r.Cancelled
+		merged.Aborted += r.Aborted
+		merged.mergeErrorBreakdown(r.ErrorBreakdown)
+		merged.FailureBodies = append(merged.FailureBodies, r.FailureBodies...)
+		merged.Redirects.merge(r.Redirects)
+		merged.Connections.merge(r.Connections)
+		merged.Phases.merge(r.Phases)
+		merged.Compression.merge(r.Compression)
+		merged.mergeServerTiming(r.ServerTiming)
+		for status, requests := range r.StatusRequests {
+			merged.StatusRequests[status] += requests
+		}
+		merged.mergeStatusStats(r.StatusStats)
+		for message, requests := range r.ValidationErrors {
+			if merged.ValidationErrors == nil {
+				merged.ValidationErrors = make(map[string]int)
+			}
+			merged.ValidationErrors[message] += requests
+		}
+		merged.mergeJSONAssertions(r.JSONAssertions)
+		for assertion, requests := range r.AssertionFailures {
+			if merged.AssertionFailures == nil {
+				merged.AssertionFailures = make(map[string]int)
+			}
+			merged.AssertionFailures[assertion] += requests
+		}
+		for protocol, requests := range r.Protocols {
+			merged.Protocols[protocol] += requests
+		}
+		if r.FastestTime < merged.FastestTime || merged.FastestTime == 0 {
+			merged.FastestTime = r.FastestTime
+		}
+		merged.SlowestTime = max(merged.SlowestTime, r.SlowestTime)
+		merged.latencies = append(merged.latencies, r.latencies...)
+		sampled = sampled || r.LatencySamples > 0
+		merged.correctedLatencies = append(merged.correctedLatencies, r.correctedLatencies...)
+		for second, bucket := range r.timeline {
+			for len(merged.timeline) <= second {
+				merged.timeline = append(merged.timeline, timelineBucket{})
+			}
+			merged.timeline[second].Requests += bucket.Requests
+			merged.timeline[second].Failed += bucket.Failed
+			merged.timeline[second].LatencySum += bucket.LatencySum
+			merged.timeline[second].NewConnections += bucket.NewConnections
+		}
+		elapsed = max(elapsed, time.Duration(r.TotalTime*float64(time.Millisecond)))
+	}
+
+	merged.finalize(elapsed, bounds)
+	if sampled {
+		merged.LatencySamples = len(merged.latencies)
+	}
+	if len(merged.latencies) == 0 {
+		merged.Histogram = mergeHistograms(reports)
+	}
+
+	endpoints := make(map[string][]*StressReport)
+	for _, r := range reports {
+		for label, endpoint := range r.Endpoints {
+			endpoints[label] = append(endpoints[label], endpoint)
+		}
+	}
+	for label, reports := range endpoints {
+		if merged.Endpoints == nil {
+			merged.Endpoints = make(map[string]*StressReport)
+		}
+		merged.Endpoints[label] = mergeReports(bounds, reports...)
+	}
+	return merged
+}
+
+// mergeHistograms adds up the histograms of reports that come without their
+// latencies, such as saved ones. It only can when they share their bucket
+// bounds, and returns nil otherwise.
+func mergeHistograms(reports []*StressReport) []HistogramBucket {
+	var merged []HistogramBucket
+	for _, r := range reports {
+		if len(r.Histogram) == 0 {
+			continue
+		}
+		if merged == nil {
+			merged = slices.Clone(r.Histogram)
+			continue
+		}
+		if !slices.EqualFunc(merged, r.Histogram, func(a, b HistogramBucket) bool { return a.Mark == b.Mark }) {
+			return nil
+		}
+		for i, bucket := range r.Histogram {
+			merged[i].Count += bucket.Count
+		}
+	}
+	return merged
+}
+
+// ServeAgent runs an agent on addr until ctx is done. The agent accepts plans
+// on POST /run from a coordinator, one at a time, and answers with the local
+// report. Plans are only run when the request carries token as a bearer
+// token, since they can target any URL.
+func ServeAgent(ctx context.Context, addr string, token string) error {
+	if token == "" {
+		return errors.New("the agent needs a token")
+	}
+	var running sync.Mutex
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/run", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(agentResult{Error: "use POST"})
+			return
+		}
+		given, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(agentResult{Error: "invalid or missing agent token"})
+			return
+		}
+		if !running.TryLock() {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(agentResult{Error: "agent is already running a test"})
+			return
+		}
+		defer running.Unlock()
+
+		var plan Plan
+		if err := json.NewDecoder(r.Body).Decode(&plan); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(agentResult{Error: err.Error()})
+			return
+		}
+
+		s := plan.newStress()
+		result := agentResult{Report: s.Report}
+		if _, err := s.Run(r.Context()); err != nil {
+			result.Error = err.Error()
+		}
+		result.Latencies = s.Report.latencies
+		result.PhaseCounts = s.Report.Phases.counts
+		for label, endpoint := range s.Report.Endpoints {
+			if result.EndpointLatencies == nil {
+				result.EndpointLatencies = make(map[string][]time.Duration)
+			}
+			result.EndpointLatencies[label] = endpoint.latencies
+		}
+		json.NewEncoder(w).Encode(result)
+	})
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
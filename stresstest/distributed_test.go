@@ -0,0 +1,66 @@
+package stresstest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlanSplit(t *testing.T) {
+	tests := []struct {
+		requests, concurrency, agents int
+		wantRequests, wantConcurrency []int
+	}{
+		{requests: 100, concurrency: 10, agents: 3, wantRequests: []int{34, 33, 33}, wantConcurrency: []int{4, 3, 3}},
+		{requests: 7, concurrency: 2, agents: 4, wantRequests: []int{2, 2, 2, 1}, wantConcurrency: []int{1, 1, 1, 1}},
+		{requests: 10, concurrency: 5, agents: 1, wantRequests: []int{10}, wantConcurrency: []int{5}},
+	}
+	for _, tt := range tests {
+		plans := Plan{Requests: tt.requests, Concurrency: tt.concurrency}.split(tt.agents)
+		for i, plan := range plans {
+			if plan.Requests != tt.wantRequests[i] || plan.Concurrency != tt.wantConcurrency[i] {
+				t.Errorf("%d requests, %d workers on %d agents: agent %d got %d requests and %d workers, want %d and %d",
+					tt.requests, tt.concurrency, tt.agents, i, plan.Requests, plan.Concurrency, tt.wantRequests[i], tt.wantConcurrency[i])
+			}
+		}
+	}
+}
+
+func TestMergeReportsPhasesAndEndpoints(t *testing.T) {
+	reports := make([]*StressReport, 2)
+	for i, ttfb := range []time.Duration{10 * time.Millisecond, 30 * time.Millisecond} {
+		r := NewStressReport()
+		r.Phases.add(requestTimings{Connect: time.Millisecond, TTFB: ttfb})
+		r.Phases.add(requestTimings{TTFB: ttfb})
+		r.endpointReport("GET /").addLatency(ttfb)
+		r.finalize(time.Second, nil)
+		for _, endpoint := range r.Endpoints {
+			endpoint.finalize(time.Second, nil)
+		}
+		reports[i] = r
+	}
+
+	merged := MergeReports(reports...)
+	if merged.Phases.TTFB != 20 || merged.Phases.Connect != 1 || merged.Phases.NewConnections != 2 {
+		t.Errorf("phases = %+v, want a TTFB of 20 ms, a connect of 1 ms and 2 new connections", merged.Phases)
+	}
+	endpoint := merged.Endpoints["GET /"]
+	if endpoint == nil {
+		t.Fatal("the endpoint was not merged")
+	}
+	if len(endpoint.latencies) != 2 {
+		t.Errorf("endpoint has %d latencies, want 2", len(endpoint.latencies))
+	}
+}
+
+func TestMergeHistograms(t *testing.T) {
+	a := &StressReport{Histogram: []HistogramBucket{{Mark: 1, Count: 2}, {Mark: 5, Count: 1}}}
+	b := &StressReport{Histogram: []HistogramBucket{{Mark: 1, Count: 3}, {Mark: 5, Count: 0}}}
+	got := mergeHistograms([]*StressReport{a, b})
+	if len(got) != 2 || got[0].Count != 5 || got[1].Count != 1 {
+		t.Errorf("merged histogram = %+v, want 5 and 1", got)
+	}
+	c := &StressReport{Histogram: []HistogramBucket{{Mark: 2, Count: 1}}}
+	if got := mergeHistograms([]*StressReport{a, c}); got != nil {
+		t.Errorf("histograms with other bounds merged into %+v", got)
+	}
+}
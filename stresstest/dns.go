@@ -0,0 +1,253 @@
+package stresstest
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// DNSMode controls how target host names are resolved for new connections.
+type DNSMode string
+
+const (
+	// DNSModeDefault resolves the host name for every new connection, so
+	// changes to the records show up during the run.
+	DNSModeDefault DNSMode = ""
+	// DNSModeOnce resolves each host name once and sends the whole test to
+	// the first address, to stress a single backend behind DNS balancing.
+	DNSModeOnce DNSMode = "once"
+	// DNSModeRoundRobin resolves each host name once and spreads new
+	// connections over all of its addresses in turn.
+	DNSModeRoundRobin DNSMode = "round-robin"
+)
+
+// IPFamily restricts connections to one address family, for host names that
+// have both A and AAAA records.
+type IPFamily string
+
+const (
+	IPFamilyAny IPFamily = ""
+	IPFamilyV4  IPFamily = "ipv4"
+	IPFamilyV6  IPFamily = "ipv6"
+)
+
+// network returns the network to use instead of tcp or udp, such as tcp4 or
+// udp6, when the family is set.
+func (f IPFamily) network(network string) string {
+	if network != "tcp" && network != "udp" {
+		return network
+	}
+	switch f {
+	case IPFamilyV4:
+		return network + "4"
+	case IPFamilyV6:
+		return network + "6"
+	}
+	return network
+}
+
+// matches reports whether ip belongs to the family.
+func (f IPFamily) matches(ip net.IP) bool {
+	switch f {
+	case IPFamilyV4:
+		return ip.To4() != nil
+	case IPFamilyV6:
+		return ip.To4() == nil
+	}
+	return true
+}
+
+// WithIPFamily connects over IPv4 or IPv6 only, so each address family of a
+// dual-stack host can be stressed on its own. The DNS modes then only pick
+// among the addresses of the family.
+func (s *Stress) WithIPFamily(family IPFamily) *Stress {
+	s.IPFamily = family
+	return s
+}
+
+// WithDNSMode changes when host names are resolved. Connections are reused,
+// so the mode only matters when new ones are opened.
+func (s *Stress) WithDNSMode(mode DNSMode) *Stress {
+	s.DNSMode = mode
+	return s
+}
+
+// WithDNSServer resolves host names with the DNS server at addr (host:port)
+// instead of the system resolver.
+func (s *Stress) WithDNSServer(addr string) *Stress {
+	s.DNSServer = addr
+	return s
+}
+
+// WithConnectTo sends the connections for hostPort, the host:port of a
+// target URL, to addr instead, like curl --connect-to. The Host header and TLS
+// server name stay those of the URL, so a single instance, such as a canary,
+// can be tested behind its public name.
+func (s *Stress) WithConnectTo(hostPort string, addr string) *Stress {
+	if s.ConnectTo == nil {
+		s.ConnectTo = make(map[string]string)
+	}
+	s.ConnectTo[hostPort] = addr
+	return s
+}
+
+// connectsElsewhere reports whether the connections for a URL host have a
+// connect-to override, using the default port of the scheme when the host has
+// none.
+func (s *Stress) connectsElsewhere(scheme string, host string) bool {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		port := "80"
+		if scheme == "https" || scheme == "wss" {
+			port = "443"
+		}
+		host = net.JoinHostPort(host, port)
+	}
+	_, ok := s.ConnectTo[host]
+	return ok
+}
+
+func (s *Stress) resolver() *net.Resolver {
+	if s.DNSServer == "" {
+		return net.DefaultResolver
+	}
+	server := s.DNSServer
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// newDialer returns the function new connections are dialed with, applying
+// the connect-to overrides, then the DNS mode and server and the IP family.
+// With a Unix socket every connection goes to the socket instead.
+func (s *Stress) newDialer(dialer *net.Dialer) (dialFunc, error) {
+	if s.UnixSocket != "" {
+		unixDialer := &net.Dialer{Timeout: dialer.Timeout}
+		return func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return unixDialer.DialContext(ctx, "unix", s.UnixSocket)
+		}, nil
+	}
+
+	switch s.IPFamily {
+	case IPFamilyAny, IPFamilyV4, IPFamilyV6:
+	default:
+		return nil, fmt.Errorf("unknown IP family %q", s.IPFamily)
+	}
+
+	dialer.Resolver = s.resolver()
+	var dial dialFunc
+	switch s.DNSMode {
+	case DNSModeDefault:
+		dial = dialer.DialContext
+	case DNSModeOnce, DNSModeRoundRobin:
+		d := &pinnedDialer{dialer: dialer, family: s.IPFamily, roundRobin: s.DNSMode == DNSModeRoundRobin, addrs: make(map[string][]string)}
+		dial = d.DialContext
+	default:
+		return nil, fmt.Errorf("unknown DNS mode %q", s.DNSMode)
+	}
+	if s.IPFamily != IPFamilyAny {
+		familyDial := dial
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return familyDial(ctx, s.IPFamily.network(network), addr)
+		}
+	}
+
+	if len(s.ConnectTo) == 0 {
+		return dial, nil
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if to, ok := s.ConnectTo[addr]; ok {
+			addr = to
+		}
+		return dial(ctx, network, addr)
+	}, nil
+}
+
+// pinnedDialer resolves each host once and dials the addresses of the family
+// it got from then on.
+type pinnedDialer struct {
+	dialer     *net.Dialer
+	family     IPFamily
+	roundRobin bool
+
+	mu    sync.Mutex
+	addrs map[string][]string
+	next  int
+}
+
+func (d *pinnedDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, addr)
+	}
+	ip, err := d.pick(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	return d.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+}
+
+func (d *pinnedDialer) pick(ctx context.Context, host string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	addrs, ok := d.addrs[host]
+	if !ok {
+		var err error
+		all, err := d.dialer.Resolver.LookupHost(ctx, host)
+		if err != nil {
+			return "", err
+		}
+		for _, addr := range all {
+			if d.family.matches(net.ParseIP(addr)) {
+				addrs = append(addrs, addr)
+			}
+		}
+		if len(addrs) == 0 {
+			return "", fmt.Errorf("%s has no %s address", host, d.family)
+		}
+		d.addrs[host] = addrs
+	}
+	if !d.roundRobin {
+		return addrs[0], nil
+	}
+	d.next++
+	return addrs[d.next%len(addrs)], nil
+}
+
+// dialTLS dials with dial and runs the TLS handshake over the connection,
+// verifying the certificate against the host of addr unless cfg names one.
+// A non-zero handshakeTimeout bounds the handshake.
+func dialTLS(ctx context.Context, dial dialFunc, network, addr string, cfg *tls.Config, handshakeTimeout time.Duration) (net.Conn, error) {
+	if cfg.ServerName == "" {
+		host, _, _ := net.SplitHostPort(addr)
+		cfg = cfg.Clone()
+		cfg.ServerName = host
+	}
+	conn, err := dial(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if handshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, handshakeTimeout)
+		defer cancel()
+	}
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
@@ -0,0 +1,84 @@
+package stresstest
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// idSegment matches path segments that identify a resource rather than name
+// an endpoint: numbers, UUIDs and long hex strings.
+var idSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+// endpointLabel names the endpoint of a request as its method and path, with
+// the query dropped and IDs replaced by :id, so /users/42 and /users/43 are
+// reported together as GET /users/:id. Template placeholders are kept as they
+// are.
+func endpointLabel(method string, rawURL string) string {
+	// The URL is cut by hand because templated ones may not parse.
+	path := rawURL
+	if i := strings.Index(path, "://"); i >= 0 {
+		path = path[i+len("://"):]
+		if j := strings.IndexByte(path, '/'); j >= 0 {
+			path = path[j:]
+		} else {
+			path = ""
+		}
+	}
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if idSegment.MatchString(segment) {
+			segments[i] = ":id"
+		}
+	}
+	path = strings.Join(segments, "/")
+	if path == "" {
+		path = "/"
+	}
+	return method + " " + path
+}
+
+// endpointReport returns the report of the endpoint with the given label,
+// creating it on first use.
+func (r *StressReport) endpointReport(label string) *StressReport {
+	if r.Endpoints == nil {
+		r.Endpoints = make(map[string]*StressReport)
+	}
+	endpoint, ok := r.Endpoints[label]
+	if !ok {
+		endpoint = r.subReport()
+		r.Endpoints[label] = endpoint
+	}
+	return endpoint
+}
+
+// writeEndpoints writes a table with the requests, error rate and latency
+// percentiles of every endpoint.
+func (r *StressReport) writeEndpoints(w io.Writer) {
+	if len(r.Endpoints) == 0 {
+		return
+	}
+	labels := make([]string, 0, len(r.Endpoints))
+	for label := range r.Endpoints {
+		labels = append(labels, label)
+	}
+	slices.Sort(labels)
+
+	fmt.Fprintln(w, "--- Endpoints ---")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Endpoint\tRequests\tErrors\tError %\tP50 (ms)\tP95 (ms)\tP99 (ms)")
+	for _, label := range labels {
+		endpoint := r.Endpoints[label]
+		errors := endpoint.Failed + endpoint.TimedOut
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f %%\t%.2f\t%.2f\t%.2f\n",
+			label, endpoint.Requests, errors, errorRate(endpoint), endpoint.P50, endpoint.P95, endpoint.P99)
+	}
+	tw.Flush()
+}
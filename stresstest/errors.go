@@ -0,0 +1,179 @@
+package stresstest
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// ErrorKind is a class of failed requests in the error breakdown.
+type ErrorKind string
+
+const (
+	// ErrorKindRequest is a request that could not be built, so nothing was
+	// sent.
+	ErrorKindRequest           ErrorKind = "request"
+	ErrorKindDNS               ErrorKind = "dns"
+	ErrorKindConnectionRefused ErrorKind = "connection_refused"
+	ErrorKindConnectionReset   ErrorKind = "connection_reset"
+	ErrorKindTLS               ErrorKind = "tls"
+	ErrorKindTimeout           ErrorKind = "timeout"
+	// ErrorKindStatus is a response whose status the success criteria do not
+	// accept.
+	ErrorKindStatus ErrorKind = "unexpected_status"
+	// ErrorKindValidation is a response rejected by the body regex or the
+	// response validator.
+	ErrorKindValidation ErrorKind = "validation"
+	// ErrorKindAssertion is a response that failed a header assertion or a
+	// JSON assertion on its body.
+	ErrorKindAssertion ErrorKind = "assertion"
+	// ErrorKindChecksum is a response whose body does not have the expected
+	// checksum.
+	ErrorKindChecksum ErrorKind = "checksum"
+	// ErrorKindSlowResponse is a response slower than the success criteria
+	// allow.
+	ErrorKindSlowResponse ErrorKind = "slow_response"
+	// ErrorKindRedirect is a 3xx response, not followed, that the success
+	// criteria do not accept.
+	ErrorKindRedirect         ErrorKind = "redirect"
+	ErrorKindTooManyRedirects ErrorKind = "too_many_redirects"
+	ErrorKindOther            ErrorKind = "other"
+)
+
+// maxErrorExamples is how many distinct messages are kept per error kind.
+const maxErrorExamples = 3
+
+// ErrorDetail counts the failed requests of one kind, with a few of their
+// distinct error messages as examples.
+type ErrorDetail struct {
+	Count    int      `json:"count"`
+	Examples []string `json:"examples,omitempty"`
+}
+
+func (d *ErrorDetail) addExample(message string) {
+	if len(d.Examples) < maxErrorExamples && !slices.Contains(d.Examples, message) {
+		d.Examples = append(d.Examples, message)
+	}
+}
+
+// requestError marks failures that happened while building the request, before
+// anything was sent.
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string { return e.err.Error() }
+
+func (e *requestError) Unwrap() error { return e.err }
+
+// checkError marks a response that failed one of the success criteria.
+type checkError struct {
+	kind ErrorKind
+	err  error
+}
+
+func (e *checkError) Error() string { return e.err.Error() }
+
+func (e *checkError) Unwrap() error { return e.err }
+
+func classifyError(err error) ErrorKind {
+	var reqErr *requestError
+	var checkErr *checkError
+	var validationErr *validationError
+	var redirectErr *redirectLimitError
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &reqErr):
+		return ErrorKindRequest
+	case errors.As(err, &checkErr):
+		return checkErr.kind
+	case errors.As(err, &validationErr):
+		return ErrorKindValidation
+	case errors.As(err, &redirectErr):
+		return ErrorKindTooManyRedirects
+	case errors.As(err, &dnsErr):
+		return ErrorKindDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorKindConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorKindConnectionReset
+	case errors.As(err, &certErr), errors.As(err, &recordErr), errors.As(err, &alertErr),
+		errors.As(err, &authorityErr), errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return ErrorKindTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorKindTimeout
+	default:
+		return ErrorKindOther
+	}
+}
+
+// addError counts a failed request in the error breakdown.
+func (r *StressReport) addError(kind ErrorKind, err error) {
+	detail := r.errorDetail(kind)
+	detail.Count++
+	detail.addExample(err.Error())
+}
+
+func (r *StressReport) mergeErrorBreakdown(breakdown map[ErrorKind]*ErrorDetail) {
+	for kind, other := range breakdown {
+		detail := r.errorDetail(kind)
+		detail.Count += other.Count
+		for _, example := range other.Examples {
+			detail.addExample(example)
+		}
+	}
+}
+
+func (r *StressReport) errorDetail(kind ErrorKind) *ErrorDetail {
+	if r.ErrorBreakdown == nil {
+		r.ErrorBreakdown = make(map[ErrorKind]*ErrorDetail)
+	}
+	detail, ok := r.ErrorBreakdown[kind]
+	if !ok {
+		detail = &ErrorDetail{}
+		r.ErrorBreakdown[kind] = detail
+	}
+	return detail
+}
+
+// writeErrorBreakdown lists the failed requests by kind, most frequent first,
+// each with its example messages.
+func (r *StressReport) writeErrorBreakdown(w io.Writer) {
+	fmt.Fprintln(w, "--- Errors ---")
+	if len(r.ErrorBreakdown) == 0 {
+		fmt.Fprintln(w, "None")
+		return
+	}
+	kinds := make([]ErrorKind, 0, len(r.ErrorBreakdown))
+	for kind := range r.ErrorBreakdown {
+		kinds = append(kinds, kind)
+	}
+	slices.SortFunc(kinds, func(a, b ErrorKind) int {
+		if r.ErrorBreakdown[a].Count != r.ErrorBreakdown[b].Count {
+			return r.ErrorBreakdown[b].Count - r.ErrorBreakdown[a].Count
+		}
+		return strings.Compare(string(a), string(b))
+	})
+	for _, kind := range kinds {
+		detail := r.ErrorBreakdown[kind]
+		fmt.Fprintln(w, string(kind)+":", detail.Count, "requests")
+		for _, example := range detail.Examples {
+			fmt.Fprintln(w, "  e.g.", example)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package stresstest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// defaultMaxFailureBodies is how many bodies are kept when no cap is set.
+const defaultMaxFailureBodies = 20
+
+// WithFailureBodies keeps the first size bytes of the response body of failed
+// requests, such as a 503 or a response the validator rejected, so the error
+// messages returned by the server show up in the verbose output and the
+// report. Only the first max bodies are kept in the report; zero keeps 20.
+func (s *Stress) WithFailureBodies(size int, max int) *Stress {
+	s.FailureBodySize = size
+	s.MaxFailureBodies = max
+	return s
+}
+
+// FailureBody is the start of the body of a failed response.
+type FailureBody struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+	Body   string `json:"body"`
+	// Truncated is set when the body was longer than the captured size.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// readBodyHead reads the first size bytes of body and discards the rest. It
+// returns the head and the total size read.
+func readBodyHead(body io.Reader, size int) ([]byte, int64, error) {
+	var head bytes.Buffer
+	n, err := io.CopyN(&head, body, int64(size))
+	if err != nil {
+		if err == io.EOF {
+			err = nil
+		}
+		return head.Bytes(), n, err
+	}
+	rest, err := io.Copy(io.Discard, body)
+	return head.Bytes(), n + rest, err
+}
+
+// failureBody returns the captured body of a failed response. The body is cut
+// at FailureBodySize bytes, backing off to a whole UTF-8 character.
+func (s *Stress) failureBody(body []byte, size int64) (string, bool) {
+	truncated := size > int64(s.FailureBodySize)
+	if len(body) > s.FailureBodySize {
+		body = body[:s.FailureBodySize]
+	}
+	if truncated {
+		for i := 0; i < utf8.UTFMax && len(body) > 0 && !utf8.Valid(body); i++ {
+			body = body[:len(body)-1]
+		}
+	}
+	return string(body), truncated
+}
+
+// addFailureBody records a captured body unless max have been kept already.
+func (r *StressReport) addFailureBody(body FailureBody, max int) {
+	if max <= 0 {
+		max = defaultMaxFailureBodies
+	}
+	if len(r.FailureBodies) < max {
+		r.FailureBodies = append(r.FailureBodies, body)
+	}
+}
+
+func (r *StressReport) writeFailureBodies(w io.Writer) {
+	if len(r.FailureBodies) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "--- Failure bodies ---")
+	for _, body := range r.FailureBodies {
+		fmt.Fprintf(w, "%s %s: %s\n", body.Method, body.URL, body.Error)
+		suffix := ""
+		if body.Truncated {
+			suffix = " (truncated)"
+		}
+		fmt.Fprintf(w, "  %q%s\n", body.Body, suffix)
+	}
+}
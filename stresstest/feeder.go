@@ -0,0 +1,139 @@
+package stresstest
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+type FeederMode string
+
+const (
+	FeederRoundRobin FeederMode = "round-robin"
+	FeederRandom     FeederMode = "random"
+)
+
+// Feeder hands out rows of test data, one per request, whose columns can be
+// used in templates as {{.column}}.
+type Feeder struct {
+	rows []map[string]string
+	mode FeederMode
+	next atomic.Uint64
+}
+
+func NewFeeder(rows []map[string]string, mode FeederMode) (*Feeder, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("feeder has no rows")
+	}
+	switch mode {
+	case FeederRoundRobin, FeederRandom:
+	case "":
+		mode = FeederRoundRobin
+	default:
+		return nil, fmt.Errorf("unknown feeder mode %q", mode)
+	}
+	return &Feeder{rows: rows, mode: mode}, nil
+}
+
+// LoadFeeder reads rows from a CSV file with a header line, or from a JSONL
+// file (one object per line) when the extension is .jsonl or .ndjson.
+func LoadFeeder(path string, mode FeederMode) (*Feeder, error) {
+	var rows []map[string]string
+	var err error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		rows, err = readJSONLRows(path)
+	default:
+		rows, err = readCSVRows(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("feeder %s: %w", path, err)
+	}
+	return NewFeeder(rows, mode)
+}
+
+// Next returns the row for the next request. It is safe for concurrent use.
+func (f *Feeder) Next() map[string]string {
+	if f.mode == FeederRandom {
+		return f.rows[rand.Intn(len(f.rows))]
+	}
+	n := f.next.Add(1) - 1
+	return f.rows[n%uint64(len(f.rows))]
+}
+
+// WithFeeder injects a row from feeder into the templates of every request.
+// It turns templating on.
+func (s *Stress) WithFeeder(feeder *Feeder) *Stress {
+	s.Feeder = feeder
+	s.Templating = true
+	return s
+}
+
+func readCSVRows(path string) ([]map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("expected a header line and at least one row")
+	}
+
+	header := records[0]
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func readJSONLRows(path string) ([]map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rows []map[string]string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var object map[string]any
+		if err := json.Unmarshal([]byte(text), &object); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		row := make(map[string]string, len(object))
+		for key, value := range object {
+			if str, ok := value.(string); ok {
+				row[key] = str
+			} else {
+				encoded, _ := json.Marshal(value)
+				row[key] = string(encoded)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
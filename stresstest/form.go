@@ -0,0 +1,126 @@
+package stresstest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FormField is one part of a multipart/form-data body: a plain field with
+// Value, a file read from File, or, when Size is set, a file of Size random
+// bytes.
+type FormField struct {
+	Name  string
+	Value string
+	File  string
+	Size  int
+	// FileName defaults to the base name of File, or to "random.bin" for
+	// random bytes.
+	FileName string
+	// ContentType of a file defaults to application/octet-stream.
+	ContentType string
+}
+
+func (f FormField) isFile() bool {
+	return f.File != "" || f.Size > 0
+}
+
+// BodyFromForm returns an application/x-www-form-urlencoded body with values,
+// and its content type.
+func BodyFromForm(values url.Values) (BodyFunc, string) {
+	return BodyFromString(values.Encode()), "application/x-www-form-urlencoded"
+}
+
+// BodyFromMultipart returns a multipart/form-data body with fields, and its
+// content type, which carries the boundary. Like BodyFromFile, the body is
+// built once, on first use, so files are read and random bytes generated only
+// once; an unreadable file fails every request.
+func BodyFromMultipart(fields ...FormField) (BodyFunc, string) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	var once sync.Once
+	var err error
+
+	body := func() (io.Reader, error) {
+		once.Do(func() {
+			err = writeMultipart(w, fields)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(buf.Bytes()), nil
+	}
+	return body, w.FormDataContentType()
+}
+
+func writeMultipart(w *multipart.Writer, fields []FormField) error {
+	for _, field := range fields {
+		if !field.isFile() {
+			if err := w.WriteField(field.Name, field.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fileName := field.FileName
+		if fileName == "" {
+			fileName = "random.bin"
+			if field.File != "" {
+				fileName = filepath.Base(field.File)
+			}
+		}
+		contentType := field.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(field.Name), escapeQuotes(fileName)))
+		header.Set("Content-Type", contentType)
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return err
+		}
+
+		if field.File != "" {
+			f, err := os.Open(field.File)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(part, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := io.CopyN(part, rand.Reader, int64(field.Size)); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// escapeQuotes escapes a Content-Disposition parameter the way
+// multipart.Writer.CreateFormFile does.
+var escapeQuotes = strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace
+
+// WithForm sends values as an application/x-www-form-urlencoded body.
+func (s *Stress) WithForm(values url.Values) *Stress {
+	s.Body, s.ContentType = BodyFromForm(values)
+	return s
+}
+
+// WithMultipart sends a multipart/form-data body with fields, for instance to
+// stress test a file upload endpoint.
+func (s *Stress) WithMultipart(fields ...FormField) *Stress {
+	s.Body, s.ContentType = BodyFromMultipart(fields...)
+	return s
+}
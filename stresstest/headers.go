@@ -0,0 +1,38 @@
+package stresstest
+
+import "net/http"
+
+// hasBody reports whether res can carry a body: responses to HEAD, 1xx, 204
+// and 304 responses never do, whatever their headers announce.
+func hasBody(res *http.Response) bool {
+	if res.Request != nil && res.Request.Method == http.MethodHead {
+		return false
+	}
+	code := res.StatusCode
+	return code >= 200 && code != http.StatusNoContent && code != http.StatusNotModified
+}
+
+// defaultAccepts reports whether code counts as success for method when no
+// status ranges are set: 200, and also the statuses that mean the same for a
+// request that asks for no body, 204 and 304 for HEAD and 204 for OPTIONS.
+func defaultAccepts(method string, code int) bool {
+	switch method {
+	case http.MethodHead:
+		return code == http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified
+	case http.MethodOptions:
+		return code == http.StatusOK || code == http.StatusNoContent
+	}
+	return code == http.StatusOK
+}
+
+// headerSize returns the size of the status line and headers of res as sent
+// in HTTP/1.1, before any HTTP/2 header compression.
+func headerSize(res *http.Response) int64 {
+	size := len(res.Proto) + len(" ") + len(res.Status) + len("\r\n")
+	for key, values := range res.Header {
+		for _, value := range values {
+			size += len(key) + len(": ") + len(value) + len("\r\n")
+		}
+	}
+	return int64(size + len("\r\n"))
+}
@@ -0,0 +1,84 @@
+package stresstest
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+const (
+	defaultHistogramBuckets = 10
+	histogramBarWidth       = 40
+)
+
+// HistogramBucket counts the requests whose latency is above the previous
+// bucket's Mark and at most this one's. Marks are in milliseconds.
+type HistogramBucket struct {
+	Mark  float64 `json:"mark"`
+	Count int     `json:"count"`
+}
+
+// computeHistogram buckets the recorded latencies. With no bounds it spreads
+// ten buckets evenly between the fastest and slowest request. A final bucket
+// at the slowest latency is added when the bounds don't already cover it.
+func (r *StressReport) computeHistogram(bounds []float64) {
+	if len(r.latencies) == 0 {
+		return
+	}
+
+	sorted := make([]float64, len(r.latencies))
+	for i, latency := range r.latencies {
+		sorted[i] = milliseconds(latency)
+	}
+	slices.Sort(sorted)
+	fastest, slowest := sorted[0], sorted[len(sorted)-1]
+
+	marks := slices.Clone(bounds)
+	if len(marks) == 0 {
+		step := (slowest - fastest) / defaultHistogramBuckets
+		for i := 1; i < defaultHistogramBuckets; i++ {
+			marks = append(marks, fastest+step*float64(i))
+		}
+		marks = append(marks, slowest)
+	}
+	slices.Sort(marks)
+	if marks[len(marks)-1] < slowest {
+		marks = append(marks, slowest)
+	}
+
+	buckets := make([]HistogramBucket, len(marks))
+	b := 0
+	for i, mark := range marks {
+		buckets[i].Mark = mark
+	}
+	for _, latency := range sorted {
+		for b < len(buckets)-1 && latency > buckets[b].Mark {
+			b++
+		}
+		buckets[b].Count++
+	}
+	r.Histogram = buckets
+}
+
+// writeHistogram renders the histogram as horizontal bars scaled to the
+// fullest bucket.
+func (r *StressReport) writeHistogram(w io.Writer) {
+	if len(r.Histogram) == 0 {
+		return
+	}
+
+	maxCount := 0
+	for _, bucket := range r.Histogram {
+		maxCount = max(maxCount, bucket.Count)
+	}
+
+	fmt.Fprintln(w, "--- Latency histogram ---")
+	for _, bucket := range r.Histogram {
+		bar := 0
+		if maxCount > 0 {
+			bar = bucket.Count * histogramBarWidth / maxCount
+		}
+		fmt.Fprintf(w, "%10.3f ms [%d]\t|%s\n", bucket.Mark, bucket.Count, strings.Repeat("■", bar))
+	}
+}
@@ -0,0 +1,26 @@
+package stresstest
+
+import "net/http"
+
+// RequestContext describes the request a BeforeRequest hook is called for.
+type RequestContext struct {
+	// VU is the ID of the virtual user sending the request.
+	VU int
+	// Target is the label of the target the request was built from.
+	Target string
+	// Attempt is 0 for the first try and counts up on retries.
+	Attempt int
+}
+
+// BeforeRequest is called with every request once it is fully built, right
+// before it is sent, to sign it or add headers computed per request. A non-nil
+// error fails the request without sending it. Hooks are called from many
+// workers at once.
+type BeforeRequest func(req *http.Request, rc RequestContext) error
+
+// WithBeforeRequest adds a hook run before every request. Hooks run in the
+// order they were added, after authentication is applied.
+func (s *Stress) WithBeforeRequest(hook BeforeRequest) *Stress {
+	s.beforeRequest = append(s.beforeRequest, hook)
+	return s
+}
@@ -0,0 +1,163 @@
+package stresstest
+
+import (
+	"html/template"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+)
+
+type htmlChartData struct {
+	Seconds   []int     `json:"seconds"`
+	Latencies []float64 `json:"latencies"`
+	Rates     []int     `json:"rates"`
+	Statuses  []string  `json:"statuses"`
+	Counts    []int     `json:"counts"`
+	Marks     []float64 `json:"marks"`
+	Buckets   []int     `json:"buckets"`
+}
+
+// WriteHTML writes a self-contained HTML page with the report summary and
+// charts of latency over time, throughput, status codes and the latency
+// histogram. It needs no network access to be viewed.
+func (r *StressReport) WriteHTML(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.HTML(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// HTML writes the page produced by WriteHTML to w.
+func (r *StressReport) HTML(w io.Writer) error {
+	var data htmlChartData
+	for _, point := range r.TimeSeries {
+		data.Seconds = append(data.Seconds, point.Second)
+		data.Latencies = append(data.Latencies, point.AverageLatency)
+		data.Rates = append(data.Rates, point.Requests)
+	}
+
+	statuses := make([]int, 0, len(r.StatusRequests))
+	for status := range r.StatusRequests {
+		statuses = append(statuses, status)
+	}
+	slices.Sort(statuses)
+	for _, status := range statuses {
+		data.Statuses = append(data.Statuses, strconv.Itoa(status))
+		data.Counts = append(data.Counts, r.StatusRequests[status])
+	}
+	if failed := r.Requests - r.Responses(); failed > 0 {
+		data.Statuses = append(data.Statuses, "error")
+		data.Counts = append(data.Counts, failed)
+	}
+
+	for _, bucket := range r.Histogram {
+		data.Marks = append(data.Marks, bucket.Mark)
+		data.Buckets = append(data.Buckets, bucket.Count)
+	}
+
+	return htmlTemplate.Execute(w, struct {
+		Report *StressReport
+		Charts htmlChartData
+	}{r, data})
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Stress test report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td { padding: 4px 12px; border-bottom: 1px solid #ddd; }
+td:first-child { font-weight: bold; }
+.charts { display: flex; flex-wrap: wrap; gap: 2em; }
+.chart { width: 560px; }
+canvas { border: 1px solid #ddd; }
+</style>
+</head>
+<body>
+<h1>Stress test report</h1>
+{{with .Report}}
+{{if .Cancelled}}<p><strong>The test was stopped early, results are partial.</strong></p>{{end}}
+<table>
+<tr><td>Requests</td><td>{{.Requests}}</td></tr>
+<tr><td>Succeeded</td><td>{{.Succeeded}} ({{printf "%.2f" .PercentageSucceeded}}%)</td></tr>
+<tr><td>Failed</td><td>{{.Failed}} ({{printf "%.2f" .PercentageFailed}}%)</td></tr>
+<tr><td>Timed out</td><td>{{.TimedOut}} ({{printf "%.2f" .PercentageTimedOut}}%)</td></tr>
+<tr><td>Total time</td><td>{{.TotalTime}} ms</td></tr>
+<tr><td>Throughput</td><td>{{printf "%.2f" .AchievedRate}} req/s</td></tr>
+<tr><td>Fastest / Slowest</td><td>{{.FastestTime}} ms / {{.SlowestTime}} ms</td></tr>
+<tr><td>P50 / P90 / P95 / P99</td><td>{{printf "%.2f" .P50}} / {{printf "%.2f" .P90}} / {{printf "%.2f" .P95}} / {{printf "%.2f" .P99}} ms</td></tr>
+<tr><td>Std dev</td><td>{{printf "%.2f" .StdDev}} ms</td></tr>
+</table>
+{{end}}
+<div class="charts">
+<div class="chart"><h3>Average latency per second (ms)</h3><canvas id="latency" width="560" height="260"></canvas></div>
+<div class="chart"><h3>Requests per second</h3><canvas id="rate" width="560" height="260"></canvas></div>
+<div class="chart"><h3>Status codes</h3><canvas id="status" width="560" height="260"></canvas></div>
+<div class="chart"><h3>Latency histogram (ms)</h3><canvas id="histogram" width="560" height="260"></canvas></div>
+</div>
+<script>
+const data = {{.Charts}};
+
+function axes(ctx, w, h, pad, maxY) {
+  ctx.strokeStyle = "#999";
+  ctx.beginPath();
+  ctx.moveTo(pad, 10); ctx.lineTo(pad, h - pad); ctx.lineTo(w - 10, h - pad);
+  ctx.stroke();
+  ctx.fillStyle = "#444";
+  ctx.font = "11px sans-serif";
+  ctx.fillText(maxY.toFixed(1), 2, 14);
+  ctx.fillText("0", pad - 12, h - pad);
+}
+
+function lineChart(id, xs, ys, color) {
+  const canvas = document.getElementById(id), ctx = canvas.getContext("2d");
+  const w = canvas.width, h = canvas.height, pad = 40;
+  const maxY = Math.max(1, ...ys);
+  axes(ctx, w, h, pad, maxY);
+  if (!ys.length) return;
+  ctx.strokeStyle = color;
+  ctx.lineWidth = 2;
+  ctx.beginPath();
+  ys.forEach((y, i) => {
+    const x = pad + (ys.length === 1 ? 0 : i * (w - pad - 10) / (ys.length - 1));
+    const py = h - pad - y / maxY * (h - pad - 10);
+    i === 0 ? ctx.moveTo(x, py) : ctx.lineTo(x, py);
+  });
+  ctx.stroke();
+  ctx.fillStyle = "#444";
+  ctx.fillText(xs[xs.length - 1] + "s", w - 30, h - pad + 14);
+}
+
+function barChart(id, labels, values, color) {
+  const canvas = document.getElementById(id), ctx = canvas.getContext("2d");
+  const w = canvas.width, h = canvas.height, pad = 40;
+  const maxY = Math.max(1, ...values);
+  axes(ctx, w, h, pad, maxY);
+  const slot = (w - pad - 10) / Math.max(1, values.length);
+  values.forEach((v, i) => {
+    const bh = v / maxY * (h - pad - 10);
+    ctx.fillStyle = color;
+    ctx.fillRect(pad + i * slot + 4, h - pad - bh, slot - 8, bh);
+    ctx.fillStyle = "#444";
+    ctx.fillText(labels[i], pad + i * slot + 4, h - pad + 14);
+    ctx.fillText(v, pad + i * slot + 4, h - pad - bh - 4);
+  });
+}
+
+lineChart("latency", data.seconds || [], data.latencies || [], "#d9534f");
+lineChart("rate", data.seconds || [], data.rates || [], "#0275d8");
+barChart("status", data.statuses || [], data.counts || [], "#5cb85c");
+barChart("histogram", (data.marks || []).map(m => m.toFixed(1)), data.buckets || [], "#f0ad4e");
+</script>
+</body>
+</html>
+`))
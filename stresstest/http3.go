@@ -0,0 +1,135 @@
+package stresstest
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// http3Transport sends requests over HTTP/3. Neither quic-go nor net/http
+// trace HTTP/3 requests, so it records their connection and first byte in
+// the request trace itself.
+type http3Transport struct {
+	rt *http3.RoundTripper
+}
+
+func (t *http3Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.rt.RoundTrip(req)
+	if err == nil {
+		if trace := contextTrace(req.Context()); trace != nil {
+			trace.gotQUICResponse()
+		}
+	}
+	return res, err
+}
+
+func (t *http3Transport) CloseIdleConnections() {
+	t.rt.CloseIdleConnections()
+}
+
+// newHTTP3Transport returns a transport that dials QUIC connections from a
+// UDP socket bound to localAddr, resolving host names as the DNS mode, the
+// DNS server and the IP family say, and applying the connect-to overrides.
+// The connection limit and the response header timeout don't apply to it.
+func (s *Stress) newHTTP3Transport(tlsConfig *tls.Config, localAddr net.Addr) (http.RoundTripper, error) {
+	if s.UnixSocket != "" {
+		return nil, errors.New("Unix sockets are not supported with HTTP/3")
+	}
+	var udpAddr *net.UDPAddr
+	if addr, ok := localAddr.(*net.TCPAddr); ok {
+		udpAddr = &net.UDPAddr{IP: addr.IP}
+	}
+	conn, err := net.ListenUDP(s.IPFamily.network("udp"), udpAddr)
+	if err != nil {
+		return nil, err
+	}
+	transport := &quic.Transport{Conn: conn}
+
+	resolve := func(ctx context.Context, host string) (string, error) {
+		addrs, err := s.resolver().LookupHost(ctx, host)
+		if err != nil {
+			return "", err
+		}
+		for _, addr := range addrs {
+			if s.IPFamily.matches(net.ParseIP(addr)) {
+				return addr, nil
+			}
+		}
+		return "", fmt.Errorf("%s has no %s address", host, s.IPFamily)
+	}
+	switch s.DNSMode {
+	case DNSModeDefault:
+	case DNSModeOnce, DNSModeRoundRobin:
+		d := &pinnedDialer{dialer: &net.Dialer{Resolver: s.resolver()}, family: s.IPFamily, roundRobin: s.DNSMode == DNSModeRoundRobin, addrs: make(map[string][]string)}
+		resolve = d.pick
+	default:
+		return nil, fmt.Errorf("unknown DNS mode %q", s.DNSMode)
+	}
+
+	dial := func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlyConnection, error) {
+		if to, ok := s.ConnectTo[addr]; ok {
+			addr = to
+		}
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		portNumber, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q", port)
+		}
+		ctx, cancel := context.WithTimeout(ctx, s.connectTimeout())
+		defer cancel()
+
+		var dns time.Duration
+		ip := host
+		if net.ParseIP(host) == nil {
+			start := time.Now()
+			if ip, err = resolve(ctx, host); err != nil {
+				return nil, err
+			}
+			dns = time.Since(start)
+		}
+		remote := &net.UDPAddr{IP: net.ParseIP(ip), Port: portNumber}
+		if !s.IPFamily.matches(remote.IP) {
+			return nil, fmt.Errorf("%s is not an %s address", ip, s.IPFamily)
+		}
+
+		start := time.Now()
+		conn, err := transport.DialEarly(ctx, remote, tlsCfg, cfg)
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-conn.HandshakeComplete():
+		case <-ctx.Done():
+			conn.CloseWithError(0, "")
+			return nil, ctx.Err()
+		}
+		if conn.Context().Err() != nil {
+			return nil, context.Cause(conn.Context())
+		}
+		if trace := contextTrace(ctx); trace != nil {
+			trace.quicDialed(dns, time.Since(start), remote.String(), conn.ConnectionState().TLS.DidResume)
+		}
+		return conn, nil
+	}
+
+	return &http3Transport{rt: &http3.RoundTripper{
+		TLSClientConfig:    tlsConfig,
+		DisableCompression: s.DisableDecompression,
+		QuicConfig: &quic.Config{
+			HandshakeIdleTimeout: s.Timeouts.TLSHandshake,
+			KeepAlivePeriod:      30 * time.Second,
+		},
+		Dial: dial,
+	}}, nil
+}
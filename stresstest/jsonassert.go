@@ -0,0 +1,346 @@
+package stresstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Comparator is how a JSONAssertion compares the value at its path.
+type Comparator string
+
+const (
+	CompareExists         Comparator = "exists"
+	CompareEqual          Comparator = "=="
+	CompareNotEqual       Comparator = "!="
+	CompareLess           Comparator = "<"
+	CompareLessOrEqual    Comparator = "<="
+	CompareGreater        Comparator = ">"
+	CompareGreaterOrEqual Comparator = ">="
+	// CompareContains looks for Expected in a string, or for an element equal
+	// to it in an array.
+	CompareContains Comparator = "contains"
+	// CompareMatches matches the value against the regular expression
+	// Expected.
+	CompareMatches Comparator = "matches"
+)
+
+var comparators = []Comparator{
+	CompareExists, CompareEqual, CompareNotEqual, CompareLessOrEqual, CompareLess,
+	CompareGreaterOrEqual, CompareGreater, CompareContains, CompareMatches,
+}
+
+// JSONAssertion checks a value of a JSON response body. Path is a JSONPath
+// subset: $ followed by object keys and array indexes, as in
+// $.data.items[0].id. Values are compared as numbers when both sides are
+// numbers and as text otherwise, objects and arrays as compact JSON.
+type JSONAssertion struct {
+	Path       string     `json:"path" yaml:"path"`
+	Comparator Comparator `json:"comparator" yaml:"comparator"`
+	Expected   string     `json:"expected,omitempty" yaml:"expected"`
+}
+
+// ParseJSONAssertion parses specs such as "$.status == ok",
+// "$.items[0].price < 100" or "$.id exists".
+func ParseJSONAssertion(spec string) (JSONAssertion, error) {
+	path, rest, _ := strings.Cut(strings.TrimSpace(spec), " ")
+	comparator, expected, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	a := JSONAssertion{Path: path, Comparator: Comparator(comparator), Expected: strings.TrimSpace(expected)}
+	if err := a.validate(); err != nil {
+		return JSONAssertion{}, fmt.Errorf("invalid JSON assertion %q: %w", spec, err)
+	}
+	return a, nil
+}
+
+func (a JSONAssertion) String() string {
+	if a.Comparator == CompareExists {
+		return a.Path + " exists"
+	}
+	return a.Path + " " + string(a.Comparator) + " " + a.Expected
+}
+
+func (a JSONAssertion) validate() error {
+	if _, err := parseJSONPath(a.Path); err != nil {
+		return err
+	}
+	if !slices.Contains(comparators, a.Comparator) {
+		return fmt.Errorf("unknown comparator %q", a.Comparator)
+	}
+	if a.Comparator == CompareMatches {
+		if _, err := regexp.Compile(a.Expected); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateJSONAssertions(assertions []JSONAssertion) error {
+	for _, assertion := range assertions {
+		if err := assertion.validate(); err != nil {
+			return fmt.Errorf("JSON assertion %q: %w", assertion, err)
+		}
+	}
+	return nil
+}
+
+// parseJSONPath splits a path such as $.a.b[0] into the keys (strings) and
+// indexes (ints) to follow.
+func parseJSONPath(path string) ([]any, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("path %q does not start with $", path)
+	}
+	var steps []any
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			if end == 1 {
+				return nil, fmt.Errorf("path %q has an empty key", path)
+			}
+			steps = append(steps, rest[1:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("path %q has an unclosed [", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("path %q has an invalid index %q", path, rest[1:end])
+			}
+			steps = append(steps, index)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("path %q is invalid at %q", path, rest)
+		}
+	}
+	return steps, nil
+}
+
+// lookup returns the value of doc at the path, and whether it exists.
+func (a JSONAssertion) lookup(doc any) (any, bool) {
+	// The path was checked by Validate.
+	steps, _ := parseJSONPath(a.Path)
+	for _, step := range steps {
+		switch step := step.(type) {
+		case string:
+			object, ok := doc.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if doc, ok = object[step]; !ok {
+				return nil, false
+			}
+		case int:
+			array, ok := doc.([]any)
+			if !ok || step >= len(array) {
+				return nil, false
+			}
+			doc = array[step]
+		}
+	}
+	return doc, true
+}
+
+// check returns why doc does not meet the assertion, or nil.
+func (a JSONAssertion) check(doc any) error {
+	value, ok := a.lookup(doc)
+	if !ok {
+		return fmt.Errorf("json %s missing", a.Path)
+	}
+	text := jsonText(value)
+
+	var passed bool
+	switch a.Comparator {
+	case CompareExists:
+		passed = true
+	case CompareEqual, CompareNotEqual:
+		passed = text == a.Expected
+		if got, want, ok := jsonNumbers(value, a.Expected); ok {
+			passed = got == want
+		}
+		if a.Comparator == CompareNotEqual {
+			passed = !passed
+		}
+	case CompareLess, CompareLessOrEqual, CompareGreater, CompareGreaterOrEqual:
+		got, want, ok := jsonNumbers(value, a.Expected)
+		if !ok {
+			return fmt.Errorf("json %s is %s, not comparable to %s", a.Path, text, a.Expected)
+		}
+		switch a.Comparator {
+		case CompareLess:
+			passed = got < want
+		case CompareLessOrEqual:
+			passed = got <= want
+		case CompareGreater:
+			passed = got > want
+		case CompareGreaterOrEqual:
+			passed = got >= want
+		}
+	case CompareContains:
+		if array, ok := value.([]any); ok {
+			passed = slices.ContainsFunc(array, func(v any) bool { return jsonText(v) == a.Expected })
+		} else {
+			passed = strings.Contains(text, a.Expected)
+		}
+	case CompareMatches:
+		passed = compileCached(a.Expected).MatchString(text)
+	}
+	if !passed {
+		return fmt.Errorf("json %s is %s, expected %s %s", a.Path, text, a.Comparator, a.Expected)
+	}
+	return nil
+}
+
+// jsonText returns v as compared with the expected text: strings as they
+// are, anything else as compact JSON.
+func jsonText(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// jsonNumbers returns v and expected as numbers when both are.
+func jsonNumbers(v any, expected string) (float64, float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, 0, false
+	}
+	got, err := n.Float64()
+	if err != nil {
+		return 0, 0, false
+	}
+	want, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return got, want, true
+}
+
+var regexpCache sync.Map
+
+// compileCached compiles a pattern checked by Validate once for all
+// responses.
+func compileCached(pattern string) *regexp.Regexp {
+	if re, ok := regexpCache.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(pattern)
+	regexpCache.Store(pattern, re)
+	return re
+}
+
+// WithJSONAssertions checks the JSON body of every response that met the
+// success criteria; targets and scenario steps can have their own. A
+// response failing one fails the request, and the report counts how many
+// responses passed and failed each assertion.
+func (s *Stress) WithJSONAssertions(assertions ...JSONAssertion) *Stress {
+	s.JSONAssertions = append(s.JSONAssertions, assertions...)
+	return s
+}
+
+// JSONAssertionStats counts the responses that passed and failed one JSON
+// assertion.
+type JSONAssertionStats struct {
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+}
+
+// jsonResult is the outcome of one assertion on one response.
+type jsonResult struct {
+	assertion string
+	passed    bool
+}
+
+// checkJSON runs the JSON assertions of the test and of spec on body. It
+// returns the outcome of each and the failure of the first one that failed,
+// or nil.
+func (s *Stress) checkJSON(spec requestSpec, body []byte) ([]jsonResult, error) {
+	assertions := append(slices.Clip(s.JSONAssertions), spec.JSONAssertions...)
+	if len(assertions) == 0 {
+		return nil, nil
+	}
+
+	var doc any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	decodeErr := decoder.Decode(&doc)
+
+	var first error
+	results := make([]jsonResult, 0, len(assertions))
+	for _, assertion := range assertions {
+		err := decodeErr
+		if err != nil {
+			err = fmt.Errorf("body is not JSON: %w", err)
+		} else {
+			err = assertion.check(doc)
+		}
+		results = append(results, jsonResult{assertion: assertion.String(), passed: err == nil})
+		if err != nil && first == nil {
+			first = &checkError{kind: ErrorKindAssertion, err: err}
+		}
+	}
+	return results, first
+}
+
+func (r *StressReport) addJSONResults(results []jsonResult) {
+	for _, result := range results {
+		if r.JSONAssertions == nil {
+			r.JSONAssertions = make(map[string]*JSONAssertionStats)
+		}
+		stats, ok := r.JSONAssertions[result.assertion]
+		if !ok {
+			stats = &JSONAssertionStats{}
+			r.JSONAssertions[result.assertion] = stats
+		}
+		if result.passed {
+			stats.Passed++
+		} else {
+			stats.Failed++
+		}
+	}
+}
+
+func (r *StressReport) mergeJSONAssertions(other map[string]*JSONAssertionStats) {
+	for assertion, stats := range other {
+		if r.JSONAssertions == nil {
+			r.JSONAssertions = make(map[string]*JSONAssertionStats)
+		}
+		merged, ok := r.JSONAssertions[assertion]
+		if !ok {
+			merged = &JSONAssertionStats{}
+			r.JSONAssertions[assertion] = merged
+		}
+		merged.Passed += stats.Passed
+		merged.Failed += stats.Failed
+	}
+}
+
+func (r *StressReport) writeJSONAssertions(w io.Writer) {
+	if len(r.JSONAssertions) == 0 {
+		return
+	}
+	assertions := make([]string, 0, len(r.JSONAssertions))
+	for assertion := range r.JSONAssertions {
+		assertions = append(assertions, assertion)
+	}
+	slices.Sort(assertions)
+
+	fmt.Fprintln(w, "--- JSON assertions ---")
+	for _, assertion := range assertions {
+		stats := r.JSONAssertions[assertion]
+		fmt.Fprintf(w, "%s: %d passed, %d failed\n", assertion, stats.Passed, stats.Failed)
+	}
+}
@@ -0,0 +1,118 @@
+package stresstest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// junitSuites is the root of a JUnit XML document, in the subset of the
+// format that Jenkins and GitLab CI read.
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitCase     `xml:"testcase"`
+	SystemOut  *junitOutput    `xml:"system-out,omitempty"`
+}
+
+// junitOutput keeps the text report readable in a CDATA section.
+type junitOutput struct {
+	Text string `xml:",cdata"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// NewJUnitReporter writes the result of the thresholds as a JUnit XML file at
+// path, one test case per checked threshold, so CI servers such as Jenkins or
+// GitLab show them in their test reports. A run without thresholds is a
+// single test case that passes. Evaluate must run before Finalize, which Run
+// does.
+func NewJUnitReporter(path string) Reporter {
+	return &fileReporter{path: path, write: func(w io.Writer, report *StressReport) error {
+		return report.junit(w)
+	}}
+}
+
+func (r *StressReport) junit(w io.Writer) error {
+	seconds := fmt.Sprintf("%.3f", r.TotalTime/1000)
+	suite := junitSuite{
+		Name: "stresstest",
+		Time: seconds,
+		Properties: []junitProperty{
+			{Name: "requests", Value: fmt.Sprint(r.Requests)},
+			{Name: "succeeded", Value: fmt.Sprint(r.Succeeded)},
+			{Name: "failed", Value: fmt.Sprint(r.Failed)},
+			{Name: "timed_out", Value: fmt.Sprint(r.TimedOut)},
+			{Name: "p95_ms", Value: fmt.Sprint(r.P95)},
+			{Name: "achieved_rate", Value: fmt.Sprintf("%.2f", r.AchievedRate)},
+		},
+	}
+	for _, result := range r.ThresholdResults {
+		c := junitCase{Name: result.Name, ClassName: "stresstest.thresholds", Time: seconds}
+		if !result.Passed {
+			c.Failure = &junitFailure{
+				Message: result.Message,
+				Type:    "threshold",
+				Text:    fmt.Sprintf("%s: %v, limit %v", result.Name, result.Value, result.Limit),
+			}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	if len(suite.Cases) == 0 {
+		suite.Cases = append(suite.Cases, junitCase{Name: "run", ClassName: "stresstest", Time: seconds})
+	}
+	suite.Tests = len(suite.Cases)
+
+	var text strings.Builder
+	r.WriteText(&text)
+	suite.SystemOut = &junitOutput{Text: text.String()}
+
+	doc := junitSuites{
+		Name:     "stresstest",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     seconds,
+		Suites:   []junitSuite{suite},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,46 @@
+package stresstest
+
+import (
+	"fmt"
+	"net"
+)
+
+// WithLocalAddr binds outgoing connections to a source address, given as an
+// IP or as the name of a network interface whose first address, of the IP
+// family when one is set, is used. It
+// is meant for multi-homed load generators and for testing rate limits keyed
+// on the client IP.
+func (s *Stress) WithLocalAddr(addr string) *Stress {
+	s.LocalAddr = addr
+	return s
+}
+
+// localAddr resolves LocalAddr to the address connections are bound to, or
+// nil when unset. It returns a net.Addr so that an unset address is a nil
+// interface rather than a nil *net.TCPAddr, which the dialer would print.
+func (s *Stress) localAddr() (net.Addr, error) {
+	if s.LocalAddr == "" {
+		return nil, nil
+	}
+	if ip := net.ParseIP(s.LocalAddr); ip != nil {
+		return &net.TCPAddr{IP: ip}, nil
+	}
+
+	iface, err := net.InterfaceByName(s.LocalAddr)
+	if err != nil {
+		return nil, fmt.Errorf("local address %q is neither an IP nor an interface: %w", s.LocalAddr, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("interface %s: %w", s.LocalAddr, err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && s.IPFamily.matches(ipNet.IP) {
+			return &net.TCPAddr{IP: ipNet.IP}, nil
+		}
+	}
+	if s.IPFamily != IPFamilyAny {
+		return nil, fmt.Errorf("interface %s has no %s address", s.LocalAddr, s.IPFamily)
+	}
+	return nil, fmt.Errorf("interface %s has no address", s.LocalAddr)
+}
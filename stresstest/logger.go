@@ -0,0 +1,127 @@
+package stresstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+type LogFormat string
+
+const (
+	LogFormatText LogFormat = "text"
+	LogFormatJSON LogFormat = "json"
+)
+
+const logBufferSize = 1024
+
+type logEntry struct {
+	VU        int     `json:"vu"`
+	Sequence  int64   `json:"sequence"`
+	Method    string  `json:"method"`
+	URL       string  `json:"url"`
+	RequestID string  `json:"request_id,omitempty"`
+	Status    int     `json:"status,omitempty"`
+	Protocol  string  `json:"protocol,omitempty"`
+	Latency   float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Body      string  `json:"body,omitempty"`
+	DNS       float64 `json:"dns_ms,omitempty"`
+	Connect   float64 `json:"connect_ms,omitempty"`
+	TLS       float64 `json:"tls_ms,omitempty"`
+	TTFB      float64 `json:"ttfb_ms,omitempty"`
+	Transfer  float64 `json:"transfer_ms,omitempty"`
+}
+
+// requestLogger writes verbose request lines from a single goroutine. Workers
+// only send entries on a channel, so lines never interleave and no worker
+// waits on a lock to log.
+type requestLogger struct {
+	w       io.Writer
+	format  LogFormat
+	entries chan logEntry
+	done    chan struct{}
+}
+
+func newRequestLogger(w io.Writer, format LogFormat) *requestLogger {
+	l := &requestLogger{
+		w:       w,
+		format:  format,
+		entries: make(chan logEntry, logBufferSize),
+		done:    make(chan struct{}),
+	}
+	go l.loop()
+	return l
+}
+
+func (l *requestLogger) loop() {
+	defer close(l.done)
+
+	for entry := range l.entries {
+		if l.format == LogFormatJSON {
+			line, _ := json.Marshal(entry)
+			fmt.Fprintln(l.w, string(line))
+			continue
+		}
+
+		line := fmt.Sprintf("VU %d | %d %s %s Time: %.0f ms", entry.VU, entry.Sequence, entry.Method, entry.URL, entry.Latency)
+		if entry.Status != 0 {
+			line += fmt.Sprintf(", Status: %d Protocol: %s", entry.Status, entry.Protocol)
+			line += fmt.Sprintf(" (DNS %.1f ms, Connect %.1f ms, TLS %.1f ms, TTFB %.1f ms, Transfer %.1f ms)",
+				entry.DNS, entry.Connect, entry.TLS, entry.TTFB, entry.Transfer)
+		}
+		if entry.RequestID != "" {
+			line += ", Request ID: " + entry.RequestID
+		}
+		if entry.Error != "" {
+			line += ", Error: " + entry.Error
+		}
+		if entry.Body != "" {
+			line += fmt.Sprintf(", Body: %q", entry.Body)
+		}
+		fmt.Fprintln(l.w, line)
+	}
+}
+
+// log queues the line of one request. method and url are those sent, with
+// the placeholders of templated requests expanded.
+func (l *requestLogger) log(vu int, method string, url string, requestID string, res *http.Response, err error, failure *FailureBody, latency time.Duration, sequence int64, timings requestTimings) {
+	entry := logEntry{
+		VU:        vu,
+		Sequence:  sequence,
+		Method:    method,
+		URL:       url,
+		RequestID: requestID,
+		Latency:   milliseconds(latency),
+		DNS:       milliseconds(timings.DNS),
+		Connect:   milliseconds(timings.Connect),
+		TLS:       milliseconds(timings.TLS),
+		TTFB:      milliseconds(timings.TTFB),
+		Transfer:  milliseconds(timings.Transfer),
+	}
+	if res != nil {
+		entry.Status = res.StatusCode
+		entry.Protocol = res.Proto
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if failure != nil {
+		entry.Body = failure.Body
+	}
+	l.entries <- entry
+}
+
+// Close flushes the pending lines.
+func (l *requestLogger) Close() {
+	close(l.entries)
+	<-l.done
+}
+
+// WithLogFormat sets how verbose request lines are written.
+func (s *Stress) WithLogFormat(format LogFormat) *Stress {
+	s.LogFormat = format
+	return s
+}
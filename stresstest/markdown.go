@@ -0,0 +1,147 @@
+package stresstest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Markdown writes a summary of the report as Markdown tables: the totals,
+// the latency percentiles, the requests per status code and per endpoint,
+// the errors and the threshold results. It renders on GitHub and GitLab,
+// for instance in a pull request comment posted by a CI job.
+func (r *StressReport) Markdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "## Stress test report")
+	fmt.Fprintln(bw)
+	if r.Cancelled {
+		fmt.Fprintln(bw, "> The test was stopped early, results are partial.")
+		fmt.Fprintln(bw)
+	}
+	fmt.Fprintln(bw, "| Metric | Value |")
+	fmt.Fprintln(bw, "| --- | ---: |")
+	fmt.Fprintf(bw, "| Requests | %d |\n", r.Requests)
+	fmt.Fprintf(bw, "| Succeeded | %d (%.2f %%) |\n", r.Succeeded, r.PercentageSucceeded)
+	fmt.Fprintf(bw, "| Failed | %d (%.2f %%) |\n", r.Failed, r.PercentageFailed)
+	fmt.Fprintf(bw, "| Timed out | %d (%.2f %%) |\n", r.TimedOut, r.PercentageTimedOut)
+	fmt.Fprintf(bw, "| Total time | %.0f ms |\n", r.TotalTime)
+	fmt.Fprintf(bw, "| Achieved rate | %.2f req/s |\n", r.AchievedRate)
+	fmt.Fprintf(bw, "| Throughput | %.2f MB/s |\n", r.Throughput)
+	fmt.Fprintln(bw)
+
+	fmt.Fprintln(bw, "### Latency (ms)")
+	fmt.Fprintln(bw)
+	fmt.Fprintln(bw, "| Average | P50 | P90 | P95 | P99 | Max |")
+	fmt.Fprintln(bw, "| ---: | ---: | ---: | ---: | ---: | ---: |")
+	fmt.Fprintf(bw, "| %.2f | %.2f | %.2f | %.2f | %.2f | %d |\n", r.AverageTime, r.P50, r.P90, r.P95, r.P99, r.SlowestTime)
+	fmt.Fprintln(bw)
+
+	r.writeMarkdownStatuses(bw)
+	r.writeMarkdownEndpoints(bw)
+	r.writeMarkdownErrors(bw)
+	r.writeMarkdownThresholds(bw)
+	return bw.Flush()
+}
+
+func (r *StressReport) writeMarkdownStatuses(w io.Writer) {
+	if len(r.StatusStats) == 0 {
+		return
+	}
+	statuses := make([]int, 0, len(r.StatusStats))
+	for status := range r.StatusStats {
+		statuses = append(statuses, status)
+	}
+	slices.Sort(statuses)
+
+	fmt.Fprintln(w, "### Status codes")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Status | Count | Percent | Min (ms) | Avg (ms) | Max (ms) |")
+	fmt.Fprintln(w, "| --- | ---: | ---: | ---: | ---: | ---: |")
+	for _, status := range statuses {
+		stats := r.StatusStats[status]
+		fmt.Fprintf(w, "| %d | %d | %.2f %% | %.2f | %.2f | %.2f |\n",
+			status, stats.Count, stats.Percentage, stats.MinLatency, stats.AverageLatency, stats.MaxLatency)
+	}
+	fmt.Fprintln(w)
+}
+
+func (r *StressReport) writeMarkdownEndpoints(w io.Writer) {
+	if len(r.Endpoints) == 0 {
+		return
+	}
+	labels := make([]string, 0, len(r.Endpoints))
+	for label := range r.Endpoints {
+		labels = append(labels, label)
+	}
+	slices.Sort(labels)
+
+	fmt.Fprintln(w, "### Endpoints")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Endpoint | Requests | Errors | Error % | P50 (ms) | P95 (ms) | P99 (ms) |")
+	fmt.Fprintln(w, "| --- | ---: | ---: | ---: | ---: | ---: | ---: |")
+	for _, label := range labels {
+		endpoint := r.Endpoints[label]
+		fmt.Fprintf(w, "| %s | %d | %d | %.2f %% | %.2f | %.2f | %.2f |\n",
+			markdownCell(label), endpoint.Requests, endpoint.Failed+endpoint.TimedOut, errorRate(endpoint), endpoint.P50, endpoint.P95, endpoint.P99)
+	}
+	fmt.Fprintln(w)
+}
+
+func (r *StressReport) writeMarkdownErrors(w io.Writer) {
+	if len(r.ErrorBreakdown) == 0 {
+		return
+	}
+	kinds := make([]ErrorKind, 0, len(r.ErrorBreakdown))
+	for kind := range r.ErrorBreakdown {
+		kinds = append(kinds, kind)
+	}
+	slices.SortFunc(kinds, func(a, b ErrorKind) int {
+		if r.ErrorBreakdown[a].Count != r.ErrorBreakdown[b].Count {
+			return r.ErrorBreakdown[b].Count - r.ErrorBreakdown[a].Count
+		}
+		return strings.Compare(string(a), string(b))
+	})
+
+	fmt.Fprintln(w, "### Errors")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Kind | Requests | Example |")
+	fmt.Fprintln(w, "| --- | ---: | --- |")
+	for _, kind := range kinds {
+		detail := r.ErrorBreakdown[kind]
+		example := ""
+		if len(detail.Examples) > 0 {
+			example = "`" + markdownCell(detail.Examples[0]) + "`"
+		}
+		fmt.Fprintf(w, "| %s | %d | %s |\n", kind, detail.Count, example)
+	}
+	fmt.Fprintln(w)
+}
+
+func (r *StressReport) writeMarkdownThresholds(w io.Writer) {
+	if len(r.ThresholdResults) == 0 {
+		return
+	}
+	verdict := "passed"
+	if len(r.ThresholdViolations) > 0 {
+		verdict = "failed"
+	}
+
+	fmt.Fprintln(w, "### Thresholds:", verdict)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Threshold | Limit | Value | Result |")
+	fmt.Fprintln(w, "| --- | ---: | ---: | --- |")
+	for _, result := range r.ThresholdResults {
+		outcome := "✅ pass"
+		if !result.Passed {
+			outcome = "❌ fail"
+		}
+		fmt.Fprintf(w, "| %s | %.2f | %.2f | %s |\n", result.Name, result.Limit, result.Value, outcome)
+	}
+	fmt.Fprintln(w)
+}
+
+// markdownCell keeps text from breaking out of a table cell.
+var markdownCell = strings.NewReplacer("|", `\|`, "\n", " ", "`", "'").Replace
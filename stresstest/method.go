@@ -0,0 +1,112 @@
+package stresstest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var knownMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// ValidateMethod returns an error unless method is one of the HTTP methods
+// defined by RFC 9110 and RFC 5789. Methods are case sensitive, so "get" is
+// rejected too.
+func ValidateMethod(method string) error {
+	for _, known := range knownMethods {
+		if method == known {
+			return nil
+		}
+	}
+	for _, known := range knownMethods {
+		if strings.EqualFold(method, known) {
+			return fmt.Errorf("unknown HTTP method %q, methods are case sensitive: use %q", method, known)
+		}
+	}
+	return fmt.Errorf("unknown HTTP method %q, expected one of %s", method, strings.Join(knownMethods, ", "))
+}
+
+// Validate checks the methods of the test, its targets and its scenario steps,
+// the expected statuses of the steps, the JSON assertions, the checksums and
+// the target of a slowloris run or connection flood, so a mistake is reported
+// before any request is sent rather than as a failure of every request. Run
+// calls it first; it can also be called right after New. The method is not
+// checked when a CallFunc sends the requests.
+func (s *Stress) Validate() error {
+	if s.Call == nil {
+		if err := ValidateMethod(s.Method); err != nil {
+			return err
+		}
+	}
+	for _, target := range s.Targets {
+		if err := validateJSONAssertions(target.JSONAssertions); err != nil {
+			return fmt.Errorf("target %s: %w", target.URL, err)
+		}
+		if target.Checksum != nil {
+			if err := target.Checksum.validate(); err != nil {
+				return fmt.Errorf("target %s: %w", target.URL, err)
+			}
+		}
+		if target.Method == "" {
+			continue
+		}
+		if err := ValidateMethod(target.Method); err != nil {
+			return fmt.Errorf("target %s: %w", target.URL, err)
+		}
+	}
+	if err := validateJSONAssertions(s.JSONAssertions); err != nil {
+		return err
+	}
+	if s.Checksum != nil {
+		if err := s.Checksum.validate(); err != nil {
+			return err
+		}
+	}
+	if s.ExcludeRampUp && s.Duration > 0 && s.Duration <= s.RampUp {
+		return fmt.Errorf("ramp-up of %s is not shorter than the duration of %s, so nothing would be measured", s.RampUp, s.Duration)
+	}
+	if s.Scenario != nil {
+		if err := s.Scenario.validate(); err != nil {
+			return fmt.Errorf("scenario %w", err)
+		}
+	}
+	if s.Slowloris != nil {
+		return s.validateSlowloris()
+	}
+	if s.ConnectFlood != nil {
+		return s.validateConnectFlood()
+	}
+	return nil
+}
+
+// validate checks the URLs, methods, expected statuses and JSON assertions of
+// the steps; an empty method means GET.
+func (sc *Scenario) validate() error {
+	for i, step := range sc.Steps {
+		if _, err := sc.stepURL(step); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if _, err := ParseStatusRanges(step.ExpectStatus...); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if err := validateJSONAssertions(step.AssertJSON); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if step.Method == "" {
+			continue
+		}
+		if err := ValidateMethod(step.Method); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
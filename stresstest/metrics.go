@@ -0,0 +1,130 @@
+package stresstest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// metricsBuckets are the latency histogram bounds exposed to Prometheus, in
+// seconds.
+var metricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// liveMetrics holds the counters served on the metrics endpoint while a test
+// is running. It is written in the Prometheus text exposition format so no
+// client library is needed.
+type liveMetrics struct {
+	inFlight atomic.Int64
+	shutdown func()
+
+	mu       sync.Mutex
+	requests map[string]int
+	counts   []int
+	sum      float64
+	count    int
+}
+
+func newLiveMetrics() *liveMetrics {
+	return &liveMetrics{
+		requests: make(map[string]int),
+		counts:   make([]int, len(metricsBuckets)),
+	}
+}
+
+// NewPrometheusReporter serves live metrics on addr (e.g. ":9090") at
+// /metrics from now until Finalize is called.
+func NewPrometheusReporter(addr string) (Reporter, error) {
+	m := newLiveMetrics()
+	shutdown, err := serveMetrics(addr, m)
+	if err != nil {
+		return nil, err
+	}
+	m.shutdown = shutdown
+	return m, nil
+}
+
+func (m *liveMetrics) addInFlight(delta int64) {
+	m.inFlight.Add(delta)
+}
+
+func (m *liveMetrics) Collect(sample Sample) {
+	code := "error"
+	if sample.Err == nil {
+		code = strconv.Itoa(sample.Status)
+	}
+	seconds := sample.Latency.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[code]++
+	for i, bound := range metricsBuckets {
+		if seconds <= bound {
+			m.counts[i]++
+		}
+	}
+	m.sum += seconds
+	m.count++
+}
+
+func (m *liveMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP stress_requests_total Requests completed, by status code.")
+	fmt.Fprintln(w, "# TYPE stress_requests_total counter")
+	codes := make([]string, 0, len(m.requests))
+	for code := range m.requests {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		fmt.Fprintf(w, "stress_requests_total{code=%q} %d\n", code, m.requests[code])
+	}
+
+	fmt.Fprintln(w, "# HELP stress_requests_in_flight Requests currently waiting for a response.")
+	fmt.Fprintln(w, "# TYPE stress_requests_in_flight gauge")
+	fmt.Fprintln(w, "stress_requests_in_flight", m.inFlight.Load())
+
+	fmt.Fprintln(w, "# HELP stress_request_duration_seconds Request latency.")
+	fmt.Fprintln(w, "# TYPE stress_request_duration_seconds histogram")
+	for i, bound := range metricsBuckets {
+		fmt.Fprintf(w, "stress_request_duration_seconds_bucket{le=%q} %d\n", strconv.FormatFloat(bound, 'g', -1, 64), m.counts[i])
+	}
+	fmt.Fprintf(w, "stress_request_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.count)
+	fmt.Fprintln(w, "stress_request_duration_seconds_sum", m.sum)
+	fmt.Fprintln(w, "stress_request_duration_seconds_count", m.count)
+}
+
+func (m *liveMetrics) Finalize(*StressReport) error {
+	m.shutdown()
+	return nil
+}
+
+// serveMetrics starts the metrics endpoint on addr and returns a function that
+// shuts it down.
+func serveMetrics(addr string, m *liveMetrics) (func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	server := &http.Server{Handler: mux}
+	go server.Serve(ln)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}, nil
+}
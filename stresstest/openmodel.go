@@ -0,0 +1,57 @@
+package stresstest
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithArrivalRate switches to an open model: requests are started at a fixed
+// rate whatever the response times, instead of by workers that wait for each
+// response. At most maxOutstanding requests are in flight at once (the
+// concurrency level when zero); later arrivals wait for a free slot. Latencies
+// are also reported measured from the scheduled start, so that waiting is
+// accounted for and the results are corrected for coordinated omission.
+func (s *Stress) WithArrivalRate(rate float64, maxOutstanding int) *Stress {
+	s.ArrivalRate = rate
+	s.MaxOutstanding = maxOutstanding
+	return s
+}
+
+// runOpenModel schedules arrivals until Requests have been scheduled, or until
+// ctx is done when running for a duration.
+func (s *Stress) runOpenModel(ctx context.Context, wg *sync.WaitGroup) {
+	maxOutstanding := s.MaxOutstanding
+	if maxOutstanding <= 0 {
+		maxOutstanding = s.Concurrency
+	}
+
+	slots := make(chan int, maxOutstanding)
+	for i := 1; i <= maxOutstanding; i++ {
+		slots <- i
+	}
+
+	start := time.Now()
+	interval := time.Duration(float64(time.Second) / s.ArrivalRate)
+
+	for i := 0; s.Duration > 0 || i < s.Requests; i++ {
+		scheduled := start.Add(time.Duration(i) * interval)
+		if !sleepContext(ctx, time.Until(scheduled)) {
+			return
+		}
+
+		var slot int
+		select {
+		case slot = <-slots:
+		case <-ctx.Done():
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { slots <- slot }()
+			s.runIteration(ctx, slot, scheduled)
+		}()
+	}
+}
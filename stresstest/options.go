@@ -0,0 +1,85 @@
+package stresstest
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures a Stress built by New. Any builder method can be used as
+// one, e.g. func(s *Stress) { s.WithRampUp(10*time.Second, true) }.
+type Option func(*Stress)
+
+// New creates a stress test against url. Without options it sends a single
+// GET request with a 30 second timeout.
+func New(url string, opts ...Option) *Stress {
+	s := &Stress{
+		URL:          url,
+		Method:       http.MethodGet,
+		Concurrency:  1,
+		Requests:     1,
+		Timeout:      30 * time.Second,
+		Report:       NewStressReport(),
+		Headers:      make(http.Header),
+		ReportFormat: ReportFormatText,
+		LogFormat:    LogFormatText,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewChecked creates a stress test like New, then validates it as Validate
+// does, so an unknown HTTP method or another mistake in the options is
+// reported at construction.
+func NewChecked(url string, opts ...Option) (*Stress, error) {
+	s := New(url, opts...)
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func WithMethod(method string) Option {
+	return func(s *Stress) { s.Method = method }
+}
+
+func WithConcurrency(concurrency int) Option {
+	return func(s *Stress) { s.Concurrency = concurrency }
+}
+
+func WithRequests(requests int) Option {
+	return func(s *Stress) { s.Requests = requests }
+}
+
+// WithTimeout sets the overall deadline of each request.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Stress) { s.Timeout = timeout }
+}
+
+func WithVerifyTLS(verify bool) Option {
+	return func(s *Stress) { s.VerifyTls = verify }
+}
+
+func WithVerbose(verbose bool) Option {
+	return func(s *Stress) { s.Verbose = verbose }
+}
+
+// WithHeaders adds every value in headers to the request headers.
+func WithHeaders(headers http.Header) Option {
+	return func(s *Stress) {
+		for key, values := range headers {
+			for _, value := range values {
+				s.WithHeader(key, value)
+			}
+		}
+	}
+}
+
+func WithBody(body BodyFunc) Option {
+	return func(s *Stress) { s.Body = body }
+}
+
+func WithContentType(contentType string) Option {
+	return func(s *Stress) { s.ContentType = contentType }
+}
@@ -0,0 +1,53 @@
+package stresstest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewCheckedValidatesMethod(t *testing.T) {
+	tests := []struct {
+		method  string
+		wantErr bool
+	}{
+		{method: "GET"},
+		{method: "PATCH"},
+		{method: "get", wantErr: true},
+		{method: "FETCH", wantErr: true},
+		{method: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		s, err := NewChecked("http://stress.test/", WithMethod(tt.method))
+		if tt.wantErr {
+			if err == nil || s != nil {
+				t.Errorf("NewChecked with method %q = %v, %v, want an error", tt.method, s, err)
+			}
+			continue
+		}
+		if err != nil || s == nil || s.Method != tt.method {
+			t.Errorf("NewChecked with method %q = %v, %v", tt.method, s, err)
+		}
+	}
+}
+
+func TestNewCheckedRejectsRampUpLongerThanDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		exclude  bool
+		wantErr  bool
+	}{
+		{duration: 10 * time.Second, exclude: true},
+		{duration: 5 * time.Second, exclude: true, wantErr: true},
+		{duration: 2 * time.Second, exclude: true, wantErr: true},
+		{duration: 2 * time.Second},
+		{exclude: true},
+	}
+	for _, tt := range tests {
+		_, err := NewChecked("http://stress.test/", func(s *Stress) {
+			s.WithDuration(tt.duration).WithRampUp(5*time.Second, tt.exclude)
+		})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("duration %s, exclude %v: err = %v, want error %v", tt.duration, tt.exclude, err, tt.wantErr)
+		}
+	}
+}
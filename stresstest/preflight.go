@@ -0,0 +1,118 @@
+package stresstest
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// WithPreflight checks every target before the load starts, so a typo in a
+// URL or host name fails the run at once with a clear error rather than as a
+// report full of failed requests. URLs are validated and their hosts
+// resolved; with probe set, one request is also sent to each target and must
+// get a response, whatever its status. Probes are not counted in the report.
+func (s *Stress) WithPreflight(probe bool) *Stress {
+	s.Preflight = true
+	s.PreflightProbe = probe
+	return s
+}
+
+// preflightSpecs returns the requests the run is going to send, one per URL.
+func (s *Stress) preflightSpecs() []requestSpec {
+	if s.Call != nil {
+		return nil
+	}
+	if s.Scenario != nil {
+		specs := make([]requestSpec, 0, len(s.Scenario.Steps))
+		for _, step := range s.Scenario.Steps {
+			stepURL, err := s.Scenario.stepURL(step)
+			if err != nil {
+				stepURL = step.Path
+			}
+			specs = append(specs, requestSpec{Label: step.Name, Method: step.Method, URL: stepURL})
+		}
+		return specs
+	}
+	if len(s.Targets) == 0 {
+		return []requestSpec{s.targetSpec(Target{URL: s.URL})}
+	}
+	specs := make([]requestSpec, 0, len(s.Targets))
+	for _, target := range s.Targets {
+		specs = append(specs, s.targetSpec(target))
+	}
+	return specs
+}
+
+func (s *Stress) preflight(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	if s.UnixSocket != "" {
+		info, err := os.Stat(s.UnixSocket)
+		if err != nil {
+			return fmt.Errorf("preflight: %w", err)
+		}
+		if info.Mode().Type() != fs.ModeSocket {
+			return fmt.Errorf("preflight: %s is not a socket", s.UnixSocket)
+		}
+	}
+
+	resolved := make(map[string]bool)
+	for _, spec := range s.preflightSpecs() {
+		// Templated URLs are only known once expanded, and scenario steps
+		// may depend on values extracted along the way.
+		if strings.Contains(spec.URL, "{{") {
+			continue
+		}
+
+		target, err := url.Parse(spec.URL)
+		if err != nil {
+			return fmt.Errorf("preflight: %w", err)
+		}
+		switch target.Scheme {
+		case "http", "https", "ws", "wss":
+		default:
+			return fmt.Errorf("preflight: %s: scheme must be http or https, or ws or wss for websocket", spec.URL)
+		}
+		if target.Hostname() == "" {
+			return fmt.Errorf("preflight: %s: no host", spec.URL)
+		}
+
+		// Behind a proxy the host is resolved by the proxy, and hosts with a
+		// connect-to override or reached over a Unix socket may not resolve
+		// at all.
+		host := target.Hostname()
+		if s.Proxy == "" && s.UnixSocket == "" && !s.connectsElsewhere(target.Scheme, target.Host) && !resolved[host] && net.ParseIP(host) == nil {
+			if _, err := s.resolver().LookupHost(ctx, host); err != nil {
+				return fmt.Errorf("preflight: %s: %w", spec.URL, err)
+			}
+			resolved[host] = true
+		}
+
+		if s.PreflightProbe && s.Scenario == nil && s.Protocol != ProtocolWebSocket {
+			if err := s.probe(ctx, spec); err != nil {
+				return fmt.Errorf("preflight: probe %s: %w", spec.URL, err)
+			}
+		}
+	}
+	return nil
+}
+
+// probe sends spec once, as the run would but outside of the report.
+func (s *Stress) probe(ctx context.Context, spec requestSpec) error {
+	vu := s.newVirtualUser(0)
+	req, err := s.newRequest(vu, spec, 0)
+	if err != nil {
+		return err
+	}
+	res, err := vu.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	_, _, err = s.readBody(res, spec)
+	return err
+}
@@ -0,0 +1,137 @@
+package stresstest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithPrewarm opens n connections to every target host before the test
+// starts, with concurrent HEAD requests, and leaves them idle in the pool, so
+// dialing and TLS handshakes don't inflate the latencies of the first
+// requests. The warm-up is reported on its own. Connections beyond
+// MaxIdleConnsPerHost, which defaults to the concurrency, are not kept, and
+// HTTP/2 multiplexes every request over one connection anyway.
+func (s *Stress) WithPrewarm(n int) *Stress {
+	s.Prewarm = n
+	return s
+}
+
+// PrewarmReport describes the connections opened before the test started.
+type PrewarmReport struct {
+	Hosts       int     `json:"hosts"`
+	Connections int     `json:"connections"`
+	Failed      int     `json:"failed"`
+	Time        float64 `json:"time_ms"`
+	// AverageSetup is the average time spent on DNS, connecting and the TLS
+	// handshake per new connection, in milliseconds.
+	AverageSetup float64  `json:"average_setup_ms"`
+	Errors       []string `json:"errors,omitempty"`
+}
+
+// prewarm opens the warm connections and records them in the report. Errors
+// don't stop the test, which would only dial the connections itself.
+func (s *Stress) prewarm(ctx context.Context) {
+	if s.Prewarm <= 0 || s.DisableKeepAlives || s.Protocol == ProtocolWebSocket {
+		return
+	}
+
+	hosts := make(map[string]string)
+	for _, spec := range s.preflightSpecs() {
+		if strings.Contains(spec.URL, "{{") {
+			continue
+		}
+		target, err := url.Parse(spec.URL)
+		if err != nil || target.Host == "" {
+			continue
+		}
+		key := target.Scheme + "://" + target.Host
+		if _, ok := hosts[key]; !ok {
+			hosts[key] = spec.URL
+		}
+	}
+	if len(hosts) == 0 {
+		return
+	}
+
+	report := &PrewarmReport{Hosts: len(hosts)}
+	var mu sync.Mutex
+	var setup time.Duration
+	var wg sync.WaitGroup
+	start := time.Now()
+	for _, target := range hosts {
+		for i := 0; i < s.Prewarm; i++ {
+			wg.Add(1)
+			go func(target string) {
+				defer wg.Done()
+				timings, err := s.prewarmRequest(ctx, target)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					report.Failed++
+					if len(report.Errors) < maxErrorExamples {
+						report.Errors = append(report.Errors, err.Error())
+					}
+					return
+				}
+				if timings.Connect > 0 {
+					report.Connections++
+					setup += timings.DNS + timings.Connect + timings.TLS
+				}
+			}(target)
+		}
+	}
+	wg.Wait()
+
+	report.Time = milliseconds(time.Since(start))
+	if report.Connections > 0 {
+		report.AverageSetup = milliseconds(setup / time.Duration(report.Connections))
+	}
+	s.Report.Prewarm = report
+}
+
+// prewarmRequest sends one HEAD request to target and drains the response,
+// so its connection goes back to the pool.
+func (s *Stress) prewarmRequest(ctx context.Context, target string) (requestTimings, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+	if err != nil {
+		return requestTimings{}, err
+	}
+	for key, values := range s.Headers {
+		if http.CanonicalHeaderKey(key) == "Host" {
+			req.Host = values[0]
+		}
+	}
+	req, trace := withTrace(req)
+	res, err := s.client.Do(req)
+	if err != nil {
+		return requestTimings{}, err
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	return trace.finish(), nil
+}
+
+func (p *PrewarmReport) writeText(w io.Writer) {
+	if p == nil {
+		return
+	}
+	fmt.Fprintln(w, "--- Prewarm (excluded) ---")
+	fmt.Fprintln(w, "Hosts:", p.Hosts)
+	fmt.Fprintln(w, "Connections:", p.Connections)
+	fmt.Fprintln(w, "Failed:", p.Failed)
+	fmt.Fprintln(w, "Time:", p.Time, "ms")
+	fmt.Fprintln(w, "AverageSetup:", p.AverageSetup, "ms")
+	for _, message := range p.Errors {
+		fmt.Fprintln(w, "Error:", message)
+	}
+}
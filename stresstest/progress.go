@@ -0,0 +1,72 @@
+package stresstest
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const progressInterval = time.Second
+
+// startProgress prints a status line every second until the returned function
+// is called. The line is rewritten in place, so it is meant for terminals.
+func (s *Stress) startProgress() func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+
+		start := time.Now()
+		lastRequests, lastCount := 0, 0
+		var lastSum time.Duration
+		for {
+			select {
+			case <-done:
+				fmt.Fprintln(s.stderr())
+				return
+			case <-ticker.C:
+			}
+
+			s.mu.Lock()
+			requests := s.Report.Requests
+			failed := s.Report.Failed
+			recent := s.Report.latencyCount - lastCount
+			sum := s.Report.latencySum - lastSum
+			lastCount, lastSum = s.Report.latencyCount, s.Report.latencySum
+			s.mu.Unlock()
+
+			var average float64
+			if recent > 0 {
+				average = milliseconds(sum / time.Duration(recent))
+			}
+			rps := float64(requests-lastRequests) / progressInterval.Seconds()
+			lastRequests = requests
+
+			completed := fmt.Sprint(requests)
+			if s.Duration == 0 && s.Scenario == nil && s.Requests > 0 {
+				completed = fmt.Sprintf("%d/%d (%.0f%%)", requests, s.Requests, float64(requests)/float64(s.Requests)*100)
+			}
+			fmt.Fprintf(s.stderr(), "\r\033[K[%s] %s requests, %.1f req/s, %d failed, avg %.2f ms",
+				time.Since(start).Round(time.Second), completed, rps, failed, average)
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// IsTerminal reports whether f is attached to a terminal, which is when the
+// live progress line makes sense.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,264 @@
+package stresstest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultPushInterval = 10 * time.Second
+
+// intervalMetrics summarizes the requests that completed during one push
+// interval. Latencies are in milliseconds.
+type intervalMetrics struct {
+	Time     time.Time // end of the interval
+	Requests int
+	Failed   int
+	Rate     float64 // requests per second
+	Mean     float64
+	P50      float64
+	P95      float64
+	P99      float64
+	Max      float64
+}
+
+// fields returns the metrics as name/value pairs, in a fixed order.
+func (m intervalMetrics) fields() [][2]string {
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return [][2]string{
+		{"requests", strconv.Itoa(m.Requests)},
+		{"failed", strconv.Itoa(m.Failed)},
+		{"rate", format(m.Rate)},
+		{"latency_mean", format(m.Mean)},
+		{"latency_p50", format(m.P50)},
+		{"latency_p95", format(m.P95)},
+		{"latency_p99", format(m.P99)},
+		{"latency_max", format(m.Max)},
+	}
+}
+
+// pushReporter aggregates samples and hands the metrics of every interval to
+// push while the test runs, and those of the last, partial interval from
+// Finalize.
+type pushReporter struct {
+	name     string
+	interval time.Duration
+	push     func(m intervalMetrics) error
+
+	mu        sync.Mutex
+	start     time.Time
+	requests  int
+	failed    int
+	latencies []time.Duration
+
+	done    chan struct{}
+	stopped chan struct{}
+	errorOutput
+}
+
+func newPushReporter(name string, interval time.Duration, push func(m intervalMetrics) error) *pushReporter {
+	if interval <= 0 {
+		interval = defaultPushInterval
+	}
+	r := &pushReporter{
+		name:     name,
+		interval: interval,
+		push:     push,
+		start:    time.Now(),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go r.loop()
+	return r
+}
+
+func (r *pushReporter) loop() {
+	defer close(r.stopped)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		}
+		// A failed push only loses one interval, so the test goes on.
+		if err := r.push(r.flush()); err != nil {
+			r.printf("%s: %v\n", r.name, err)
+		}
+	}
+}
+
+func (r *pushReporter) Collect(sample Sample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests++
+	if sample.Err != nil || sample.CheckErr != nil {
+		r.failed++
+	}
+	r.latencies = append(r.latencies, sample.Latency)
+}
+
+// flush returns the metrics of the interval so far and starts the next one.
+func (r *pushReporter) flush() intervalMetrics {
+	r.mu.Lock()
+	now := time.Now()
+	m := intervalMetrics{Time: now, Requests: r.requests, Failed: r.failed}
+	latencies := r.latencies
+	elapsed := now.Sub(r.start)
+	r.start, r.requests, r.failed, r.latencies = now, 0, 0, nil
+	r.mu.Unlock()
+
+	if elapsed > 0 {
+		m.Rate = float64(m.Requests) / elapsed.Seconds()
+	}
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		var sum time.Duration
+		for _, latency := range latencies {
+			sum += latency
+		}
+		m.Mean = milliseconds(sum / time.Duration(len(latencies)))
+		m.P50 = percentile(latencies, 50)
+		m.P95 = percentile(latencies, 95)
+		m.P99 = percentile(latencies, 99)
+		m.Max = milliseconds(latencies[len(latencies)-1])
+	}
+	return m
+}
+
+func (r *pushReporter) Finalize(*StressReport) error {
+	close(r.done)
+	<-r.stopped
+	if err := r.push(r.flush()); err != nil {
+		return fmt.Errorf("%s: %w", r.name, err)
+	}
+	return nil
+}
+
+// InfluxConfig says where NewInfluxReporter writes.
+type InfluxConfig struct {
+	// URL is the write endpoint, such as http://localhost:8086/write?db=stress
+	// for InfluxDB 1 or
+	// http://localhost:8086/api/v2/write?org=ORG&bucket=BUCKET for InfluxDB 2.
+	URL string
+	// Token is sent as "Authorization: Token ..." when set, as InfluxDB 2
+	// expects.
+	Token string
+	// Measurement defaults to "stresstest".
+	Measurement string
+	// Tags are added to every point, for instance to tell runs apart.
+	Tags     map[string]string
+	Interval time.Duration
+}
+
+// NewInfluxReporter writes the metrics of every interval (10 seconds by
+// default) to InfluxDB as a point in line protocol while the test runs, so it
+// can be graphed in Grafana next to the server side metrics.
+func NewInfluxReporter(cfg InfluxConfig) (Reporter, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("influx: no write URL")
+	}
+	if cfg.Measurement == "" {
+		cfg.Measurement = "stresstest"
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	return newPushReporter("influx", cfg.Interval, func(m intervalMetrics) error {
+		req, err := http.NewRequest(http.MethodPost, cfg.URL, strings.NewReader(influxLine(cfg.Measurement, cfg.Tags, m)))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		if cfg.Token != "" {
+			req.Header.Set("Authorization", "Token "+cfg.Token)
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode/100 != 2 {
+			body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+			return fmt.Errorf("write returned %s: %s", res.Status, bytes.TrimSpace(body))
+		}
+		return nil
+	}), nil
+}
+
+// influxLine formats m as a line protocol point, with integer fields marked
+// as such and a nanosecond timestamp.
+func influxLine(measurement string, tags map[string]string, m intervalMetrics) string {
+	escape := strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+	var b strings.Builder
+	b.WriteString(escape.Replace(measurement))
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, ",%s=%s", escape.Replace(key), escape.Replace(tags[key]))
+	}
+	for i, field := range m.fields() {
+		sep := ","
+		if i == 0 {
+			sep = " "
+		}
+		value := field[1]
+		if field[0] == "requests" || field[0] == "failed" {
+			value += "i"
+		}
+		fmt.Fprintf(&b, "%s%s=%s", sep, field[0], value)
+	}
+	fmt.Fprintf(&b, " %d\n", m.Time.UnixNano())
+	return b.String()
+}
+
+// GraphiteConfig says where NewGraphiteReporter writes.
+type GraphiteConfig struct {
+	// Addr is the host:port of the plaintext protocol listener, usually on
+	// port 2003.
+	Addr string
+	// Prefix is prepended to every metric name and defaults to "stresstest".
+	Prefix   string
+	Interval time.Duration
+}
+
+// NewGraphiteReporter sends the metrics of every interval (10 seconds by
+// default) to Graphite over the plaintext protocol while the test runs, as
+// PREFIX.requests, PREFIX.latency_p95 and so on.
+func NewGraphiteReporter(cfg GraphiteConfig) (Reporter, error) {
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("graphite: no address")
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "stresstest"
+	}
+
+	return newPushReporter("graphite", cfg.Interval, func(m intervalMetrics) error {
+		var b strings.Builder
+		for _, field := range m.fields() {
+			fmt.Fprintf(&b, "%s.%s %s %d\n", cfg.Prefix, field[0], field[1], m.Time.Unix())
+		}
+		conn, err := net.DialTimeout("tcp", cfg.Addr, 10*time.Second)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		_, err = io.WriteString(conn, b.String())
+		return err
+	}), nil
+}
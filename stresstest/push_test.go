@@ -0,0 +1,45 @@
+package stresstest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPushReporterWritesErrorsToErrorOutput(t *testing.T) {
+	var finalized atomic.Bool
+	r := newPushReporter("test", 5*time.Millisecond, func(intervalMetrics) error {
+		if finalized.Load() {
+			return nil
+		}
+		return errors.New("push refused")
+	})
+
+	var errOut strings.Builder
+	s := New("http://stress.test/", WithConcurrency(1), WithRequests(5))
+	s.WithTransport(&fakeTransport{delay: 10 * time.Millisecond})
+	s.WithReporter(finalizeHook{r, func() { finalized.Store(true) }})
+	s.SetErrorOutput(&errOut)
+
+	if _, err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !strings.Contains(errOut.String(), "test: push refused") {
+		t.Errorf("error output = %q, want the push error", errOut.String())
+	}
+}
+
+// finalizeHook calls before ahead of the Finalize of the push reporter it
+// wraps, so the last push can behave differently.
+type finalizeHook struct {
+	*pushReporter
+	before func()
+}
+
+func (r finalizeHook) Finalize(report *StressReport) error {
+	r.before()
+	return r.pushReporter.Finalize(report)
+}
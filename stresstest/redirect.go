@@ -0,0 +1,115 @@
+package stresstest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultMaxRedirects is the limit net/http applies when none is set.
+const defaultMaxRedirects = 10
+
+// WithDisableRedirects records 3xx responses as they are instead of following
+// them. Unless the success criteria accept them, they are reported as
+// redirect errors.
+func (s *Stress) WithDisableRedirects(disable bool) *Stress {
+	s.DisableRedirects = disable
+	return s
+}
+
+// WithMaxRedirects caps how many redirects a request follows; a request
+// redirected more often fails with a too_many_redirects error. Zero keeps the
+// default of 10.
+func (s *Stress) WithMaxRedirects(n int) *Stress {
+	s.MaxRedirects = n
+	return s
+}
+
+// RedirectStats describes the redirects that were followed.
+type RedirectStats struct {
+	// Redirected counts the requests that followed at least one redirect and
+	// Hops the redirects followed in total.
+	Redirected int `json:"redirected"`
+	Hops       int `json:"hops"`
+	// AverageTime is the average time, in milliseconds, a redirected request
+	// spent on the chain before its final request was sent.
+	AverageTime float64 `json:"average_time_ms"`
+	sum         time.Duration
+}
+
+func (r *RedirectStats) add(chain *redirectChain) {
+	if chain.hops == 0 {
+		return
+	}
+	r.Redirected++
+	r.Hops += chain.hops
+	r.sum += chain.last.Sub(chain.start)
+}
+
+func (r *RedirectStats) merge(other RedirectStats) {
+	r.Redirected += other.Redirected
+	r.Hops += other.Hops
+	r.sum += time.Duration(other.AverageTime*float64(time.Millisecond)) * time.Duration(other.Redirected)
+}
+
+func (r *RedirectStats) finalize() {
+	if r.Redirected > 0 {
+		r.AverageTime = milliseconds(r.sum / time.Duration(r.Redirected))
+	}
+}
+
+func (r *RedirectStats) writeText(w io.Writer) {
+	if r.Redirected == 0 {
+		return
+	}
+	fmt.Fprintln(w, "--- Redirects ---")
+	fmt.Fprintln(w, "Redirected:", r.Redirected, "requests")
+	fmt.Fprintln(w, "Hops:", r.Hops)
+	fmt.Fprintln(w, "AverageTime:", r.AverageTime, "ms")
+}
+
+// redirectChain follows one request through its redirects.
+type redirectChain struct {
+	start time.Time
+	hops  int
+	last  time.Time // when the last redirect was followed
+}
+
+type redirectChainKey struct{}
+
+// withRedirectChain returns req with a chain that checkRedirect updates.
+func withRedirectChain(req *http.Request) (*http.Request, *redirectChain) {
+	chain := &redirectChain{start: time.Now()}
+	return req.WithContext(context.WithValue(req.Context(), redirectChainKey{}, chain)), chain
+}
+
+// redirectLimitError is returned when a request is redirected more than
+// MaxRedirects times.
+type redirectLimitError struct {
+	max int
+}
+
+func (e *redirectLimitError) Error() string {
+	return fmt.Sprintf("stopped after %d redirects", e.max)
+}
+
+// checkRedirect is the CheckRedirect of the client.
+func (s *Stress) checkRedirect(req *http.Request, via []*http.Request) error {
+	if s.DisableRedirects {
+		return http.ErrUseLastResponse
+	}
+	max := s.MaxRedirects
+	if max <= 0 {
+		max = defaultMaxRedirects
+	}
+	if len(via) > max {
+		return &redirectLimitError{max: max}
+	}
+	if chain, ok := req.Context().Value(redirectChainKey{}).(*redirectChain); ok {
+		chain.hops = len(via)
+		chain.last = time.Now()
+	}
+	return nil
+}
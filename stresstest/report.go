@@ -0,0 +1,456 @@
+package stresstest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type MapStatusRequests map[int]int
+
+type ReportFormat string
+
+const (
+	ReportFormatText ReportFormat = "text"
+	ReportFormatJSON ReportFormat = "json"
+	// ReportFormatMarkdown writes the summary tables of StressReport.Markdown.
+	ReportFormatMarkdown ReportFormat = "markdown"
+)
+
+type StressReport struct {
+	Requests            int                            `json:"requests"`
+	Failed              int                            `json:"failed"`
+	Succeeded           int                            `json:"succeeded"`
+	TimedOut            int                            `json:"timed_out"`
+	TotalTime           float64                        `json:"total_time"`
+	AverageTime         float64                        `json:"average_time"`
+	FastestTime         int64                          `json:"fastest_time"`
+	SlowestTime         int64                          `json:"slowest_time"`
+	PercentageSucceeded float64                        `json:"percentage_succeeded"`
+	PercentageFailed    float64                        `json:"percentage_failed"`
+	PercentageTimedOut  float64                        `json:"percentage_timed_out"`
+	P50                 float64                        `json:"p50"`
+	P90                 float64                        `json:"p90"`
+	P95                 float64                        `json:"p95"`
+	P99                 float64                        `json:"p99"`
+	StdDev              float64                        `json:"std_dev"`
+	CorrectedP50        float64                        `json:"corrected_p50,omitempty"`
+	CorrectedP90        float64                        `json:"corrected_p90,omitempty"`
+	CorrectedP95        float64                        `json:"corrected_p95,omitempty"`
+	CorrectedP99        float64                        `json:"corrected_p99,omitempty"`
+	RequestedRate       float64                        `json:"requested_rate"`
+	AchievedRate        float64                        `json:"achieved_rate"`
+	BytesReceived       int64                          `json:"bytes_received"`
+	AverageSize         float64                        `json:"average_size"`
+	HeaderBytesReceived int64                          `json:"header_bytes_received"`
+	AverageHeaderSize   float64                        `json:"average_header_size"`
+	Throughput          float64                        `json:"throughput_mb_per_second"`
+	StatusRequests      MapStatusRequests              `json:"status_requests"`
+	StatusStats         map[int]*StatusStats           `json:"status_stats"`
+	Protocols           map[string]int                 `json:"protocols"`
+	Phases              PhaseTimings                   `json:"phases"`
+	Connections         ConnectionStats                `json:"connections"`
+	Prewarm             *PrewarmReport                 `json:"prewarm,omitempty"`
+	Redirects           RedirectStats                  `json:"redirects"`
+	Compression         CompressionStats               `json:"compression"`
+	ErrorBreakdown      map[ErrorKind]*ErrorDetail     `json:"error_breakdown,omitempty"`
+	FailureBodies       []FailureBody                  `json:"failure_bodies,omitempty"`
+	Cancelled           bool                           `json:"cancelled"`
+	Aborted             int                            `json:"aborted"`
+	ValidationErrors    map[string]int                 `json:"validation_errors,omitempty"`
+	AssertionFailures   map[string]int                 `json:"assertion_failures,omitempty"`
+	JSONAssertions      map[string]*JSONAssertionStats `json:"json_assertions,omitempty"`
+	ServerTiming        map[string]*ServerTimingStats  `json:"server_timing,omitempty"`
+	ThresholdViolations []string                       `json:"threshold_violations,omitempty"`
+	ThresholdResults    []ThresholdResult              `json:"threshold_results,omitempty"`
+	WarmUpRequests      int                            `json:"warm_up_requests"`
+	Retries             int                            `json:"retries"`
+	SucceededAfterRetry int                            `json:"succeeded_after_retry"`
+	Histogram           []HistogramBucket              `json:"histogram"`
+	TimeSeries          []TimeSeriesPoint              `json:"time_series"`
+	Endpoints           map[string]*StressReport       `json:"endpoints,omitempty"`
+	Stages              []*StageReport                 `json:"stages,omitempty"`
+	Spikes              []SpikeReport                  `json:"spikes,omitempty"`
+	Snapshots           []Snapshot                     `json:"snapshots,omitempty"`
+	WebSocket           *WebSocketStats                `json:"websocket,omitempty"`
+	Slowloris           *SlowlorisStats                `json:"slowloris,omitempty"`
+	ConnectFlood        *ConnectFloodStats             `json:"connect_flood,omitempty"`
+	LatencySamples      int                            `json:"latency_samples,omitempty"`
+	latencies           []time.Duration
+	correctedLatencies  []time.Duration
+	latencyCount        int
+	correctedCount      int
+	latencySum          time.Duration
+	maxSamples          int
+	window              []time.Duration // every latency since the last drain, while keepWindow is set
+	keepWindow          bool
+	timeline            []timelineBucket
+}
+
+// TimeSeriesPoint summarizes the requests that completed during one second of
+// the run. Second is 1 for the first second. NewConnections counts the
+// connections opened during that second.
+type TimeSeriesPoint struct {
+	Second         int     `json:"second"`
+	Requests       int     `json:"requests"`
+	RPS            float64 `json:"rps"`
+	ErrorRate      float64 `json:"error_rate"`
+	AverageLatency float64 `json:"average_latency"`
+	NewConnections int     `json:"new_connections"`
+}
+
+// timelineBucket aggregates the requests that completed during one second of
+// the run.
+type timelineBucket struct {
+	Requests       int
+	Failed         int
+	LatencySum     time.Duration
+	NewConnections int
+}
+
+func NewStressReport() *StressReport {
+	return &StressReport{
+		Requests:            0,
+		Failed:              0,
+		Succeeded:           0,
+		TimedOut:            0,
+		TotalTime:           0,
+		AverageTime:         0,
+		FastestTime:         0,
+		SlowestTime:         0,
+		PercentageSucceeded: 0,
+		PercentageFailed:    0,
+		PercentageTimedOut:  0,
+		P50:                 0,
+		P90:                 0,
+		P95:                 0,
+		P99:                 0,
+		StdDev:              0,
+		RequestedRate:       0,
+		AchievedRate:        0,
+		StatusRequests:      make(MapStatusRequests),
+		StatusStats:         make(map[int]*StatusStats),
+		Protocols:           make(map[string]int),
+	}
+}
+
+// JSON returns the report encoded as indented JSON. Times are in milliseconds.
+func (r *StressReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+func (r *StressReport) WriteText(w io.Writer) {
+	fmt.Fprintln(w, "--- Report ---")
+	if r.Cancelled {
+		fmt.Fprintln(w, "Cancelled: the test was stopped early, results are partial")
+	}
+	if r.Aborted > 0 {
+		fmt.Fprintln(w, "Aborted:", r.Aborted, "requests in flight (not counted)")
+	}
+	fmt.Fprintln(w, "Requests:", r.Requests)
+	if r.WarmUpRequests > 0 {
+		fmt.Fprintln(w, "WarmUpRequests:", r.WarmUpRequests, "(excluded)")
+	}
+	fmt.Fprintln(w, "Failed:", r.Failed)
+	fmt.Fprintln(w, "Succeeded:", r.Succeeded)
+	fmt.Fprintln(w, "TimedOut:", r.TimedOut)
+	if r.Retries > 0 {
+		fmt.Fprintln(w, "Retries:", r.Retries)
+		fmt.Fprintln(w, "SucceededAfterRetry:", r.SucceededAfterRetry)
+	}
+	fmt.Fprintln(w, "TotalTime:", r.TotalTime, "ms")
+	fmt.Fprintln(w, "AverageTime:", r.AverageTime, "ms")
+	fmt.Fprintln(w, "FastestTime:", r.FastestTime, "ms")
+	fmt.Fprintln(w, "SlowestTime:", r.SlowestTime, "ms")
+	fmt.Fprintln(w, "P50:", r.P50, "ms")
+	fmt.Fprintln(w, "P90:", r.P90, "ms")
+	fmt.Fprintln(w, "P95:", r.P95, "ms")
+	fmt.Fprintln(w, "P99:", r.P99, "ms")
+	fmt.Fprintln(w, "StdDev:", r.StdDev, "ms")
+	if r.LatencySamples > 0 {
+		fmt.Fprintln(w, "LatencySamples:", r.LatencySamples, "(percentiles are estimated from a random sample)")
+	}
+	if len(r.correctedLatencies) > 0 {
+		fmt.Fprintln(w, "CorrectedP50:", r.CorrectedP50, "ms")
+		fmt.Fprintln(w, "CorrectedP90:", r.CorrectedP90, "ms")
+		fmt.Fprintln(w, "CorrectedP95:", r.CorrectedP95, "ms")
+		fmt.Fprintln(w, "CorrectedP99:", r.CorrectedP99, "ms")
+	}
+	if r.RequestedRate > 0 {
+		fmt.Fprintln(w, "RequestedRate:", r.RequestedRate, "req/s")
+	}
+	fmt.Fprintln(w, "AchievedRate:", r.AchievedRate, "req/s")
+	fmt.Fprintln(w, "BytesReceived:", r.BytesReceived, "bytes")
+	fmt.Fprintln(w, "AverageSize:", r.AverageSize, "bytes")
+	fmt.Fprintln(w, "HeaderBytesReceived:", r.HeaderBytesReceived, "bytes")
+	fmt.Fprintln(w, "AverageHeaderSize:", r.AverageHeaderSize, "bytes")
+	fmt.Fprintln(w, "Throughput:", r.Throughput, "MB/s")
+	fmt.Fprintln(w, "PercentageSucceeded:", r.PercentageSucceeded, "%")
+	fmt.Fprintln(w, "PercentageFailed:", r.PercentageFailed, "%")
+	fmt.Fprintln(w, "PercentageTimedOut:", r.PercentageTimedOut, "%")
+	r.Phases.writeText(w)
+	r.Connections.writeText(w)
+	r.Prewarm.writeText(w)
+	r.Redirects.writeText(w)
+	r.Compression.writeText(w)
+	r.writeServerTiming(w)
+	r.writeHistogram(w)
+	r.writeTimeCharts(w)
+	fmt.Fprintln(w, "--- Requests per status code ---")
+	r.writeStatusStats(w)
+	fmt.Fprintln(w, "--- Requests per protocol ---")
+	for protocol, requests := range r.Protocols {
+		fmt.Fprintln(w, protocol+":", requests, "requests")
+	}
+	r.writeErrorBreakdown(w)
+	r.writeFailureBodies(w)
+	r.writeValidationErrors(w)
+	r.writeAssertionFailures(w)
+	r.writeJSONAssertions(w)
+	r.writeEndpoints(w)
+	r.writeStages(w)
+	r.writeSpikes(w)
+	r.writeSnapshots(w)
+	if len(r.ThresholdViolations) > 0 {
+		fmt.Fprintln(w, "--- Thresholds not met ---")
+		for _, violation := range r.ThresholdViolations {
+			fmt.Fprintln(w, violation)
+		}
+	}
+	if r.WebSocket != nil {
+		r.WebSocket.writeText(w)
+	}
+	if r.Slowloris != nil {
+		r.Slowloris.writeText(w)
+	}
+	if r.ConnectFlood != nil {
+		r.ConnectFlood.writeText(w)
+	}
+}
+
+// Responses returns how many requests got an HTTP response, whatever the status.
+func (r *StressReport) Responses() int {
+	responses := 0
+	for _, requests := range r.StatusRequests {
+		responses += requests
+	}
+	return responses
+}
+
+// record adds the outcome of a single request to the report. checkErr is set
+// when a response was received but did not meet the success criteria or was
+// rejected by the validator.
+func (r *StressReport) record(res *http.Response, err error, checkErr error, latency time.Duration) {
+	if err != nil {
+		kind := classifyError(err)
+		r.addError(kind, err)
+		// Timeouts are counted on their own rather than as failures, so
+		// Succeeded + Failed + TimedOut always adds up to Requests.
+		if kind == ErrorKindTimeout {
+			r.TimedOut++
+		} else {
+			r.Failed++
+		}
+	} else {
+		var validationErr *validationError
+		if errors.As(checkErr, &validationErr) {
+			r.addValidationError(validationErr)
+		}
+		var assertionErr *assertionError
+		if errors.As(checkErr, &assertionErr) {
+			r.addAssertionFailure(assertionErr.assertion)
+		}
+		if checkErr != nil {
+			r.addError(classifyError(checkErr), checkErr)
+			r.Failed++
+		} else {
+			r.Succeeded++
+		}
+		if _, ok := r.StatusRequests[res.StatusCode]; !ok {
+			r.StatusRequests[res.StatusCode] = 0
+		}
+		r.StatusRequests[res.StatusCode]++
+		r.Protocols[res.Proto]++
+	}
+
+	r.Requests++
+
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return
+	}
+
+	r.addLatency(latency)
+	if err == nil {
+		r.addStatusLatency(res.StatusCode, latency)
+	}
+
+	elapsed := latency.Milliseconds()
+	if elapsed < r.FastestTime || r.FastestTime == 0 {
+		r.FastestTime = elapsed
+	}
+
+	if elapsed > r.SlowestTime {
+		r.SlowestTime = elapsed
+	}
+}
+
+// finalize computes the derived fields once all requests are recorded.
+func (r *StressReport) finalize(elapsed time.Duration, histogramBounds []float64) {
+	r.TotalTime = float64(elapsed.Milliseconds())
+	if r.Requests > 0 {
+		r.AverageTime = r.TotalTime / float64(r.Requests)
+		r.PercentageSucceeded = float64(r.Succeeded) / float64(r.Requests) * 100
+		r.PercentageFailed = float64(r.Failed) / float64(r.Requests) * 100
+		r.PercentageTimedOut = float64(r.TimedOut) / float64(r.Requests) * 100
+	}
+	if responses := r.Responses(); responses > 0 {
+		r.AverageSize = float64(r.BytesReceived) / float64(responses)
+		r.AverageHeaderSize = float64(r.HeaderBytesReceived) / float64(responses)
+	}
+	if elapsed > 0 {
+		r.AchievedRate = float64(r.Requests) / elapsed.Seconds()
+		r.Throughput = float64(r.BytesReceived) / 1e6 / elapsed.Seconds()
+	}
+	if r.latencyCount > len(r.latencies) {
+		r.LatencySamples = len(r.latencies)
+	}
+	r.computeLatencyStats()
+	r.computeStatusStats()
+	r.Phases.finalize()
+	r.Connections.finalize()
+	r.Redirects.finalize()
+	r.Compression.finalize()
+	r.finalizeServerTiming()
+	r.computeHistogram(histogramBounds)
+	r.computeTimeSeries()
+	if r.WebSocket != nil {
+		r.WebSocket.finalize(elapsed)
+	}
+	if r.Slowloris != nil {
+		r.Slowloris.finalize()
+	}
+	if r.ConnectFlood != nil {
+		r.ConnectFlood.finalize(elapsed)
+	}
+}
+
+func (r *StressReport) computeTimeSeries() {
+	r.TimeSeries = make([]TimeSeriesPoint, len(r.timeline))
+	for i, bucket := range r.timeline {
+		point := TimeSeriesPoint{
+			Second:         i + 1,
+			Requests:       bucket.Requests,
+			RPS:            float64(bucket.Requests),
+			NewConnections: bucket.NewConnections,
+		}
+		if bucket.Requests > 0 {
+			point.ErrorRate = float64(bucket.Failed) / float64(bucket.Requests) * 100
+			point.AverageLatency = milliseconds(bucket.LatencySum / time.Duration(bucket.Requests))
+		}
+		r.TimeSeries[i] = point
+	}
+}
+
+// timelineFromTimeSeries rebuilds the raw buckets from a decoded report, so
+// reports received from elsewhere can be merged.
+func timelineFromTimeSeries(series []TimeSeriesPoint) []timelineBucket {
+	timeline := make([]timelineBucket, len(series))
+	for i, point := range series {
+		timeline[i] = timelineBucket{
+			Requests:       point.Requests,
+			Failed:         int(math.Round(point.ErrorRate / 100 * float64(point.Requests))),
+			LatencySum:     time.Duration(point.AverageLatency * float64(point.Requests) * float64(time.Millisecond)),
+			NewConnections: point.NewConnections,
+		}
+	}
+	return timeline
+}
+
+func (r *StressReport) addLatency(elapsed time.Duration) {
+	r.latencyCount++
+	r.latencySum += elapsed
+	r.latencies = addSample(r.latencies, r.latencyCount, r.maxSamples, elapsed)
+	if r.keepWindow {
+		r.window = append(r.window, elapsed)
+	}
+}
+
+// addToTimeline records a request that completed offset into the run.
+func (r *StressReport) addToTimeline(offset time.Duration, latency time.Duration, failed bool) {
+	if offset < 0 {
+		offset = 0
+	}
+	second := int(offset / time.Second)
+	for len(r.timeline) <= second {
+		r.timeline = append(r.timeline, timelineBucket{})
+	}
+	r.timeline[second].Requests++
+	r.timeline[second].LatencySum += latency
+	if failed {
+		r.timeline[second].Failed++
+	}
+}
+
+// addCorrectedLatency records a latency measured from the scheduled start of
+// an open model request rather than from when it was actually sent.
+func (r *StressReport) addCorrectedLatency(elapsed time.Duration) {
+	r.correctedCount++
+	r.correctedLatencies = addSample(r.correctedLatencies, r.correctedCount, r.maxSamples, elapsed)
+}
+
+// computeLatencyStats fills the percentile and standard deviation fields from
+// the recorded latencies. All values are in milliseconds.
+func (r *StressReport) computeLatencyStats() {
+	if len(r.latencies) == 0 {
+		return
+	}
+
+	sorted := slices.Clone(r.latencies)
+	slices.Sort(sorted)
+
+	r.P50 = percentile(sorted, 50)
+	r.P90 = percentile(sorted, 90)
+	r.P95 = percentile(sorted, 95)
+	r.P99 = percentile(sorted, 99)
+
+	if len(r.correctedLatencies) > 0 {
+		corrected := slices.Clone(r.correctedLatencies)
+		slices.Sort(corrected)
+		r.CorrectedP50 = percentile(corrected, 50)
+		r.CorrectedP90 = percentile(corrected, 90)
+		r.CorrectedP95 = percentile(corrected, 95)
+		r.CorrectedP99 = percentile(corrected, 99)
+	}
+
+	var sum float64
+	for _, latency := range sorted {
+		sum += milliseconds(latency)
+	}
+	mean := sum / float64(len(sorted))
+
+	var variance float64
+	for _, latency := range sorted {
+		diff := milliseconds(latency) - mean
+		variance += diff * diff
+	}
+	r.StdDev = math.Sqrt(variance / float64(len(sorted)))
+}
+
+// percentile uses the nearest-rank method on an already sorted slice.
+func percentile(sorted []time.Duration, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return milliseconds(sorted[rank-1])
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
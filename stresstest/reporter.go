@@ -0,0 +1,222 @@
+package stresstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sample is the outcome of a single request, handed to every Reporter as soon
+// as the request completes.
+type Sample struct {
+	Start   time.Time
+	Worker  int
+	Target  string
+	Method  string
+	URL     string
+	Status  int // zero when no response was received
+	Latency time.Duration
+	// Err is set when the request could not be sent or its response read;
+	// CheckErr when a response arrived but failed the success criteria.
+	Err      error
+	CheckErr error
+	// TraceParent is the W3C traceparent header sent with the request, when
+	// trace propagation is on.
+	TraceParent string
+	// RequestID is the ID sent in the request ID header, when one is set.
+	RequestID string
+}
+
+// Reporter is a sink for the results of a run. Collect is called concurrently
+// from the workers for every request; Finalize once, with the aggregated
+// report, after the last request has finished.
+type Reporter interface {
+	Collect(sample Sample)
+	Finalize(report *StressReport) error
+}
+
+// WithReporter registers r to receive the results of the run. It can be called
+// more than once to write the same run to several sinks.
+func (s *Stress) WithReporter(r Reporter) *Stress {
+	s.reporters = append(s.reporters, r)
+	return s
+}
+
+// inFlightTracker is implemented by reporters that also want to know when a
+// request is sent, not only when it completes.
+type inFlightTracker interface {
+	addInFlight(delta int64)
+}
+
+// errorWriter is implemented by reporters that print errors from their own
+// goroutine, so they go to the error output of the Stress they run with.
+type errorWriter interface {
+	setErrorOutput(w io.Writer)
+}
+
+// errorOutput is embedded by those reporters. Until the Stress sets it, errors
+// go to os.Stderr.
+type errorOutput struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (o *errorOutput) setErrorOutput(w io.Writer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.w = w
+}
+
+func (o *errorOutput) printf(format string, args ...any) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	w := o.w
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, format, args...)
+}
+
+func (s *Stress) collect(sample Sample) {
+	for _, r := range s.sinks {
+		r.Collect(sample)
+	}
+}
+
+func (s *Stress) addInFlight(delta int64) {
+	for _, r := range s.sinks {
+		if t, ok := r.(inFlightTracker); ok {
+			t.addInFlight(delta)
+		}
+	}
+}
+
+// streamReporter writes every sample as a JSON line as soon as it completes.
+type streamReporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+type streamLine struct {
+	Timestamp string  `json:"timestamp"`
+	Worker    int     `json:"worker"`
+	Method    string  `json:"method"`
+	URL       string  `json:"url"`
+	Status    int     `json:"status,omitempty"`
+	Latency   float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// NewStreamReporter writes one JSON object per completed request to w
+// (newline-delimited JSON), so results can be followed while the test runs.
+func NewStreamReporter(w io.Writer) Reporter {
+	return &streamReporter{w: w}
+}
+
+func (r *streamReporter) Collect(sample Sample) {
+	line := streamLine{
+		Timestamp: sample.Start.Format(time.RFC3339Nano),
+		Worker:    sample.Worker,
+		Method:    sample.Method,
+		URL:       sample.URL,
+		Status:    sample.Status,
+		Latency:   milliseconds(sample.Latency),
+	}
+	if sample.Err != nil {
+		line.Error = sample.Err.Error()
+	} else if sample.CheckErr != nil {
+		line.Error = sample.CheckErr.Error()
+	}
+	data, _ := json.Marshal(line)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.w.Write(append(data, '\n'))
+}
+
+func (r *streamReporter) Finalize(*StressReport) error {
+	return nil
+}
+
+// writerReporter writes the final report to w in the given format.
+type writerReporter struct {
+	w      io.Writer
+	format ReportFormat
+}
+
+// NewConsoleReporter writes the final report to w, usually os.Stdout, as text
+// or JSON.
+func NewConsoleReporter(w io.Writer, format ReportFormat) Reporter {
+	return &writerReporter{w: w, format: format}
+}
+
+func (r *writerReporter) Collect(Sample) {}
+
+func (r *writerReporter) Finalize(report *StressReport) error {
+	return writeReport(r.w, report, r.format)
+}
+
+// fileReporter writes the final report to a file created when the run ends.
+type fileReporter struct {
+	path  string
+	write func(w io.Writer, report *StressReport) error
+}
+
+// NewJSONFileReporter writes the final report as JSON to the file at path.
+func NewJSONFileReporter(path string) Reporter {
+	return &fileReporter{path: path, write: func(w io.Writer, report *StressReport) error {
+		return writeReport(w, report, ReportFormatJSON)
+	}}
+}
+
+// NewHTMLReporter writes the page produced by StressReport.HTML to the file at
+// path.
+func NewHTMLReporter(path string) Reporter {
+	return &fileReporter{path: path, write: func(w io.Writer, report *StressReport) error {
+		return report.HTML(w)
+	}}
+}
+
+// NewSaveReporter saves the final report to the file at path with
+// StressReport.Save.
+func NewSaveReporter(path string) Reporter {
+	return &fileReporter{path: path, write: func(w io.Writer, report *StressReport) error {
+		return report.save(w)
+	}}
+}
+
+func (r *fileReporter) Collect(Sample) {}
+
+func (r *fileReporter) Finalize(report *StressReport) error {
+	f, err := os.Create(r.path)
+	if err != nil {
+		return err
+	}
+	if err := r.write(f, report); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeReport(w io.Writer, report *StressReport, format ReportFormat) error {
+	switch format {
+	case ReportFormatJSON:
+		data, err := report.JSON()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case ReportFormatMarkdown:
+		return report.Markdown(w)
+	case ReportFormatText, "":
+		report.WriteText(w)
+		return nil
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
@@ -0,0 +1,36 @@
+package stresstest
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// DefaultRequestIDHeader is the header WithRequestID is usually given.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// WithRequestID sends a unique ID in the given header, such as X-Request-ID,
+// with every request. The ID is also written to the verbose log lines and the
+// samples CSV, so a failed request can be found in the server logs. An empty
+// header turns it off.
+func (s *Stress) WithRequestID(header string) *Stress {
+	s.RequestIDHeader = header
+	return s
+}
+
+// newRequestID returns a random (version 4) UUID.
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// requestID returns the ID sent with req, if any.
+func (s *Stress) requestID(req *http.Request) string {
+	if s.RequestIDHeader == "" {
+		return ""
+	}
+	return req.Header.Get(s.RequestIDHeader)
+}
@@ -0,0 +1,45 @@
+package stresstest
+
+import (
+	"net/http"
+	"time"
+)
+
+// RetryPolicy retries requests that fail in a way worth trying again. Only
+// the last attempt is counted as the request; the earlier ones show up in
+// the report's Retries.
+type RetryPolicy struct {
+	// Attempts is how many times a request is retried after the first try.
+	Attempts int
+	// Backoff is the pause before the first retry. It doubles for each
+	// following one.
+	Backoff time.Duration
+	// StatusCodes are the response statuses that are retried, e.g. 502-504.
+	StatusCodes []StatusRange
+	// OnError retries requests that got no response at all, such as refused
+	// connections or timeouts.
+	OnError bool
+}
+
+// WithRetryPolicy retries failed requests according to p.
+func (s *Stress) WithRetryPolicy(p RetryPolicy) *Stress {
+	s.Retry = p
+	return s
+}
+
+func (p RetryPolicy) retryable(res *http.Response, err error) bool {
+	if err != nil {
+		return p.OnError
+	}
+	for _, r := range p.StatusCodes {
+		if res.StatusCode >= r.Min && res.StatusCode <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the pause before retry number n, counting from zero.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	return p.Backoff << n
+}
@@ -0,0 +1,37 @@
+package stresstest
+
+import (
+	"math/rand"
+	"time"
+)
+
+// WithMaxSamples caps how many latencies are kept for percentiles, the
+// histogram and exports, so that runs of millions of requests fit in memory.
+// Past the cap the kept latencies are a uniform random sample of all of them
+// (reservoir sampling) and percentiles become estimates. Counts, rates and
+// the time series stay exact. Zero keeps every latency.
+func (s *Stress) WithMaxSamples(n int) *Stress {
+	s.MaxSamples = n
+	return s
+}
+
+// addSample adds v, the seen-th value, to samples. Once max values are kept
+// it replaces a random one with probability max/seen instead, which keeps
+// samples a uniform sample of every value seen.
+func addSample(samples []time.Duration, seen int, max int, v time.Duration) []time.Duration {
+	if max <= 0 || len(samples) < max {
+		return append(samples, v)
+	}
+	if j := rand.Intn(seen); j < max {
+		samples[j] = v
+	}
+	return samples
+}
+
+// subReport returns an empty report for a part of the run, such as a target
+// or a stage, with the same sampling cap as r.
+func (r *StressReport) subReport() *StressReport {
+	sub := NewStressReport()
+	sub.maxSamples = r.maxSamples
+	return sub
+}
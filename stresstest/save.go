@@ -0,0 +1,60 @@
+package stresstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// savedReport is the file format of Save: the JSON report along with the raw
+// latencies, so a loaded report keeps its exact percentiles when merged with
+// others. Files holding only the JSON report load too.
+type savedReport struct {
+	*StressReport
+	Latencies          []time.Duration `json:"latencies,omitempty"`
+	CorrectedLatencies []time.Duration `json:"corrected_latencies,omitempty"`
+}
+
+// Save writes the report to path so it can be archived and later printed
+// again, converted or compared after loading it with LoadReport.
+func (r *StressReport) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (r *StressReport) save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(savedReport{
+		StressReport:       r,
+		Latencies:          r.latencies,
+		CorrectedLatencies: r.correctedLatencies,
+	})
+}
+
+// LoadReport reads a report written by Save, or the JSON report of a run.
+func LoadReport(path string) (*StressReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	saved := savedReport{StressReport: NewStressReport()}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	r := saved.StressReport
+	r.latencies = saved.Latencies
+	r.correctedLatencies = saved.CorrectedLatencies
+	r.timeline = timelineFromTimeSeries(r.TimeSeries)
+	return r, nil
+}
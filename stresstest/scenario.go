@@ -0,0 +1,125 @@
+package stresstest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is an ordered list of steps that every virtual user walks through,
+// e.g. login, browse, checkout. When a scenario is set, each iteration of a
+// worker runs all of its steps and Requests counts iterations.
+type Scenario struct {
+	Name    string `yaml:"name" json:"name"`
+	BaseURL string `yaml:"base_url" json:"base_url"`
+	Steps   []Step `yaml:"steps" json:"steps"`
+}
+
+type Step struct {
+	Name        string            `yaml:"name" json:"name"`
+	Method      string            `yaml:"method" json:"method"`
+	Path        string            `yaml:"path" json:"path"`
+	Headers     map[string]string `yaml:"headers" json:"headers"`
+	Body        string            `yaml:"body" json:"body"`
+	ContentType string            `yaml:"content_type" json:"content_type"`
+	ThinkTime   time.Duration     `yaml:"think_time" json:"think_time"`
+	// ExpectStatus lists the statuses the step succeeds with, such as "201"
+	// or "4xx", instead of those of the success criteria.
+	ExpectStatus []string `yaml:"expect_status" json:"expect_status"`
+	// AssertJSON are checked on the JSON body of the step's responses.
+	AssertJSON []JSONAssertion `yaml:"assert_json" json:"assert_json"`
+}
+
+// LoadScenario reads a scenario from a YAML or JSON file. Since JSON is valid
+// YAML, both are parsed the same way.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var scenario Scenario
+	if err := yaml.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("parse scenario %s: %w", path, err)
+	}
+	if len(scenario.Steps) == 0 {
+		return nil, fmt.Errorf("scenario %s has no steps", path)
+	}
+	if err := scenario.validate(); err != nil {
+		return nil, fmt.Errorf("scenario %s %w", path, err)
+	}
+	return &scenario, nil
+}
+
+// WithScenario runs the given scenario instead of a single request per
+// iteration.
+func (s *Stress) WithScenario(scenario *Scenario) *Stress {
+	s.Scenario = scenario
+	return s
+}
+
+// stepURL resolves a step's path against the scenario base URL. Absolute
+// paths are used as is; a relative path without a base URL is an error.
+func (sc *Scenario) stepURL(step Step) (string, error) {
+	ref, err := url.Parse(step.Path)
+	if err != nil {
+		return "", err
+	}
+	if sc.BaseURL != "" && !ref.IsAbs() {
+		base, err := url.Parse(sc.BaseURL)
+		if err != nil {
+			return "", err
+		}
+		ref = base.ResolveReference(ref)
+	}
+	if !ref.IsAbs() || ref.Host == "" {
+		return "", fmt.Errorf("%q is not an absolute URL, set base_url or use a full URL", ref)
+	}
+	return ref.String(), nil
+}
+
+func (s *Stress) runScenario(ctx context.Context, worker int) {
+	for _, step := range s.Scenario.Steps {
+		if ctx.Err() != nil {
+			return
+		}
+
+		stepURL, _ := s.Scenario.stepURL(step)
+		spec := requestSpec{
+			Label:       step.Name,
+			Method:      step.Method,
+			URL:         stepURL,
+			ContentType: step.ContentType,
+		}
+		if spec.Label == "" {
+			spec.Label = stepURL
+		}
+		if spec.Method == "" {
+			spec.Method = http.MethodGet
+		}
+		spec.Endpoint = step.Name
+		if spec.Endpoint == "" {
+			spec.Endpoint = endpointLabel(spec.Method, stepURL)
+		}
+		if step.Body != "" {
+			spec.Body = BodyFromString(step.Body)
+		}
+		// The statuses were checked by Validate.
+		spec.ExpectStatus, _ = ParseStatusRanges(step.ExpectStatus...)
+		spec.JSONAssertions = step.AssertJSON
+		for key, value := range step.Headers {
+			if spec.Headers == nil {
+				spec.Headers = make(map[string][]string)
+			}
+			spec.Headers[key] = []string{value}
+		}
+
+		s.runRequest(ctx, worker, spec)
+		sleepContext(ctx, step.ThinkTime)
+	}
+}
@@ -0,0 +1,60 @@
+package stresstest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadScenarioResolvesStepURLs(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "relative path with base URL",
+			yaml: "base_url: http://stress.test/api/\nsteps:\n  - path: login\n",
+			want: "http://stress.test/api/login",
+		},
+		{
+			name: "absolute URL without base URL",
+			yaml: "steps:\n  - path: http://stress.test/login\n",
+			want: "http://stress.test/login",
+		},
+		{
+			name:    "relative path without base URL",
+			yaml:    "steps:\n  - path: /login\n",
+			wantErr: "step 1: \"/login\" is not an absolute URL",
+		},
+		{
+			name:    "base URL without host",
+			yaml:    "base_url: /api/\nsteps:\n  - path: http://stress.test/\n  - path: login\n",
+			wantErr: "step 2: \"/api/login\" is not an absolute URL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scenario.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			scenario, err := LoadScenario(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadScenario error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadScenario: %v", err)
+			}
+			if got, _ := scenario.stepURL(scenario.Steps[0]); got != tt.want {
+				t.Errorf("step URL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
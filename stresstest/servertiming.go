@@ -0,0 +1,142 @@
+package stresstest
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerTimingStats aggregates one metric of the Server-Timing response
+// headers, such as db or app, over the responses that reported a duration for
+// it.
+type ServerTimingStats struct {
+	Responses int `json:"responses"`
+	// Average and Max are the durations, in milliseconds, the server reported.
+	Average float64 `json:"average_ms"`
+	Max     float64 `json:"max_ms"`
+	// ClientAverage is the average latency measured by the client for the
+	// same responses. What Average leaves of it was spent on the network, in
+	// queues or in server work the metric does not cover.
+	ClientAverage float64 `json:"client_average_ms"`
+	sum           float64
+	clientSum     float64
+}
+
+// serverTimings returns the durations, in milliseconds, of the metrics of the
+// Server-Timing headers of res that have one, as in "db;dur=12.5, app;dur=3".
+// A metric listed more than once keeps its first duration.
+func serverTimings(res *http.Response) map[string]float64 {
+	var timings map[string]float64
+	for _, value := range res.Header.Values("Server-Timing") {
+		for _, metric := range splitQuoted(value, ',') {
+			params := splitQuoted(metric, ';')
+			name := strings.TrimSpace(params[0])
+			if name == "" {
+				continue
+			}
+			for _, param := range params[1:] {
+				key, value, _ := strings.Cut(param, "=")
+				if !strings.EqualFold(strings.TrimSpace(key), "dur") {
+					continue
+				}
+				dur, err := strconv.ParseFloat(strings.Trim(strings.TrimSpace(value), `"`), 64)
+				if err != nil || dur < 0 || math.IsInf(dur, 0) {
+					break
+				}
+				if timings == nil {
+					timings = make(map[string]float64)
+				}
+				if _, ok := timings[name]; !ok {
+					timings[name] = dur
+				}
+				break
+			}
+		}
+	}
+	return timings
+}
+
+// splitQuoted splits s around sep, except inside double-quoted strings such
+// as the desc parameter of a metric.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func (r *StressReport) addServerTiming(res *http.Response, latency time.Duration) {
+	for name, dur := range serverTimings(res) {
+		if r.ServerTiming == nil {
+			r.ServerTiming = make(map[string]*ServerTimingStats)
+		}
+		stats, ok := r.ServerTiming[name]
+		if !ok {
+			stats = &ServerTimingStats{}
+			r.ServerTiming[name] = stats
+		}
+		stats.Responses++
+		stats.sum += dur
+		stats.clientSum += milliseconds(latency)
+		stats.Max = max(stats.Max, dur)
+	}
+}
+
+func (r *StressReport) mergeServerTiming(other map[string]*ServerTimingStats) {
+	for name, stats := range other {
+		if r.ServerTiming == nil {
+			r.ServerTiming = make(map[string]*ServerTimingStats)
+		}
+		merged, ok := r.ServerTiming[name]
+		if !ok {
+			merged = &ServerTimingStats{}
+			r.ServerTiming[name] = merged
+		}
+		merged.Responses += stats.Responses
+		merged.sum += stats.Average * float64(stats.Responses)
+		merged.clientSum += stats.ClientAverage * float64(stats.Responses)
+		merged.Max = max(merged.Max, stats.Max)
+	}
+}
+
+func (r *StressReport) finalizeServerTiming() {
+	for _, stats := range r.ServerTiming {
+		if stats.Responses > 0 {
+			stats.Average = stats.sum / float64(stats.Responses)
+			stats.ClientAverage = stats.clientSum / float64(stats.Responses)
+		}
+	}
+}
+
+func (r *StressReport) writeServerTiming(w io.Writer) {
+	if len(r.ServerTiming) == 0 {
+		return
+	}
+	names := make([]string, 0, len(r.ServerTiming))
+	for name := range r.ServerTiming {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	fmt.Fprintln(w, "--- Server-Timing (average) ---")
+	for _, name := range names {
+		stats := r.ServerTiming[name]
+		fmt.Fprintf(w, "%s: %g ms (max %g ms, client %g ms, %d responses)\n", name, stats.Average, stats.Max, stats.ClientAverage, stats.Responses)
+	}
+}
@@ -0,0 +1,58 @@
+package stresstest
+
+import (
+	"context"
+	"time"
+)
+
+// WithGracePeriod bounds how long the requests in flight may keep running
+// once the context given to Run is cancelled. Past it they are aborted
+// and left out of the report, except for the Aborted count. Zero lets them
+// run until they time out.
+func (s *Stress) WithGracePeriod(d time.Duration) *Stress {
+	s.GracePeriod = d
+	return s
+}
+
+// Abort cancels the requests in flight at once, as a second Ctrl+C does. It
+// is meant to be called once the run context is cancelled; scheduling only
+// stops with that context.
+func (s *Stress) Abort() {
+	s.mu.Lock()
+	abort := s.abort
+	s.mu.Unlock()
+	if abort != nil {
+		abort()
+	}
+}
+
+// startInFlight sets up the context requests are sent with. It outlives ctx
+// by the grace period, so that stopping the run lets started requests finish.
+func (s *Stress) startInFlight(ctx context.Context) (stop func()) {
+	inFlight, abort := context.WithCancel(context.WithoutCancel(ctx))
+	s.mu.Lock()
+	s.inFlight, s.abort = inFlight, abort
+	s.mu.Unlock()
+
+	stopWatching := context.AfterFunc(ctx, func() {
+		if s.GracePeriod > 0 {
+			time.AfterFunc(s.GracePeriod, abort)
+		}
+	})
+	return func() {
+		stopWatching()
+		abort()
+	}
+}
+
+// aborted reports whether err comes from Abort or the end of the grace
+// period, in which case the request is counted as aborted and nothing else.
+func (s *Stress) aborted(err error) bool {
+	if err == nil || s.inFlight.Err() == nil {
+		return false
+	}
+	s.mu.Lock()
+	s.Report.Aborted++
+	s.mu.Unlock()
+	return true
+}
@@ -0,0 +1,290 @@
+package stresstest
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSlowlorisInterval = 10 * time.Second
+	// slowlorisBodySize is the body length announced in body mode, large
+	// enough never to be sent in full.
+	slowlorisBodySize = 1 << 20
+)
+
+// SlowlorisConfig configures a slowloris run, see WithSlowloris.
+type SlowlorisConfig struct {
+	// Interval is how long a connection waits between two writes, 10 seconds
+	// by default.
+	Interval time.Duration
+	// Body sends complete headers announcing a large body and then the body,
+	// one byte per interval, instead of never finishing the headers. GET
+	// becomes POST.
+	Body bool
+}
+
+// WithSlowloris turns the run into a slowloris test of the target's timeout
+// and connection limit defenses: Concurrency connections send a request
+// without ever finishing it, one more header line (or body byte) per
+// Interval, and are opened again when the server closes them. The run lasts
+// Duration, or Timeout when no duration is set. Only the slowloris section of
+// the report is filled in; it says how many connections the server tolerated
+// and for how long.
+func (s *Stress) WithSlowloris(cfg SlowlorisConfig) *Stress {
+	s.Slowloris = &cfg
+	return s
+}
+
+// SlowlorisStats describes the connections of a slowloris run. Times are in
+// milliseconds.
+type SlowlorisStats struct {
+	Connections     int `json:"connections"`
+	ConnectFailures int `json:"connect_failures"`
+	// Closed counts the connections the server closed, or answered, before
+	// the end of the run and Open those it still kept open at the end.
+	Closed   int `json:"closed"`
+	Open     int `json:"open"`
+	PeakOpen int `json:"peak_open"`
+	// Responses counts the statuses the server answered with before closing,
+	// such as 408 Request Timeout.
+	Responses map[int]int `json:"responses,omitempty"`
+	BytesSent int64       `json:"bytes_sent"`
+	// HeldAverage, HeldP50 and HeldMax are how long the server kept the
+	// connections it closed.
+	HeldAverage float64 `json:"held_average_ms"`
+	HeldP50     float64 `json:"held_p50_ms"`
+	HeldMax     float64 `json:"held_max_ms"`
+	held        []time.Duration
+	open        int
+}
+
+func (l *SlowlorisStats) finalize() {
+	if len(l.held) == 0 {
+		return
+	}
+	sorted := slices.Clone(l.held)
+	slices.Sort(sorted)
+	var sum time.Duration
+	for _, held := range sorted {
+		sum += held
+	}
+	l.HeldAverage = milliseconds(sum / time.Duration(len(sorted)))
+	l.HeldP50 = percentile(sorted, 50)
+	l.HeldMax = milliseconds(sorted[len(sorted)-1])
+}
+
+func (l *SlowlorisStats) writeText(w io.Writer) {
+	fmt.Fprintln(w, "--- Slowloris ---")
+	fmt.Fprintln(w, "Connections:", l.Connections)
+	fmt.Fprintln(w, "ConnectFailures:", l.ConnectFailures)
+	fmt.Fprintln(w, "Closed:", l.Closed)
+	fmt.Fprintln(w, "Open:", l.Open)
+	fmt.Fprintln(w, "PeakOpen:", l.PeakOpen)
+	fmt.Fprintln(w, "BytesSent:", l.BytesSent, "bytes")
+	if l.Closed > 0 {
+		fmt.Fprintln(w, "HeldAverage:", l.HeldAverage, "ms")
+		fmt.Fprintln(w, "HeldP50:", l.HeldP50, "ms")
+		fmt.Fprintln(w, "HeldMax:", l.HeldMax, "ms")
+	}
+	statuses := make([]int, 0, len(l.Responses))
+	for status := range l.Responses {
+		statuses = append(statuses, status)
+	}
+	slices.Sort(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(w, "Response %d: %d connections\n", status, l.Responses[status])
+	}
+}
+
+// runSlowloris holds one slow connection per worker.
+func (s *Stress) runSlowloris(ctx context.Context, wg *sync.WaitGroup) {
+	s.Report.Slowloris = &SlowlorisStats{}
+
+	// The URL was checked by Validate.
+	target, _ := url.Parse(s.URL)
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		s.lastErr = err
+		return
+	}
+	localAddr, err := s.localAddr()
+	if err != nil {
+		s.lastErr = err
+		return
+	}
+	dial, err := s.newDialer(&net.Dialer{Timeout: s.connectTimeout(), LocalAddr: localAddr})
+	if err != nil {
+		s.lastErr = err
+		return
+	}
+
+	for i := 0; i < s.Concurrency; i++ {
+		wg.Add(1)
+		i := i
+
+		go func() {
+			defer wg.Done()
+			ctx := ctx
+			if s.Duration <= 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+				defer cancel()
+			}
+			if !sleepContext(ctx, s.rampUpDelay(i)) {
+				return
+			}
+			for ctx.Err() == nil {
+				conn, err := s.dialSlowloris(ctx, dial, tlsConfig, target)
+				if err != nil {
+					s.mu.Lock()
+					s.Report.Slowloris.ConnectFailures++
+					s.Report.addError(classifyError(err), err)
+					s.lastErr = err
+					s.mu.Unlock()
+					// Don't spin while the server refuses connections.
+					sleepContext(ctx, time.Second)
+					continue
+				}
+				// A connection closed early is opened again only an interval
+				// after it was, so a server answering right away is not
+				// flooded.
+				held := s.holdSlowloris(ctx, conn, target)
+				sleepContext(ctx, s.slowlorisInterval()-held)
+			}
+		}()
+	}
+}
+
+func (s *Stress) dialSlowloris(ctx context.Context, dial dialFunc, tlsConfig *tls.Config, target *url.URL) (net.Conn, error) {
+	addr := hostPort(target)
+	if target.Scheme == "https" {
+		return dialTLS(ctx, dial, "tcp", addr, tlsConfig, s.Timeouts.TLSHandshake)
+	}
+	return dial(ctx, "tcp", addr)
+}
+
+// slowlorisRequest returns the start of the request a connection sends.
+func (s *Stress) slowlorisRequest(target *url.URL) string {
+	method := s.Method
+	if s.Slowloris.Body && (method == http.MethodGet || method == http.MethodHead) {
+		method = http.MethodPost
+	}
+	host := target.Host
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s HTTP/1.1\r\n", method, target.RequestURI())
+	for key, values := range s.Headers {
+		if http.CanonicalHeaderKey(key) == "Host" {
+			host = values[0]
+			continue
+		}
+		for _, value := range values {
+			fmt.Fprintf(&b, "%s: %s\r\n", key, value)
+		}
+	}
+	fmt.Fprintf(&b, "Host: %s\r\n", host)
+	if s.Slowloris.Body {
+		fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n", slowlorisBodySize)
+	}
+	return b.String()
+}
+
+func (s *Stress) slowlorisInterval() time.Duration {
+	if s.Slowloris.Interval <= 0 {
+		return defaultSlowlorisInterval
+	}
+	return s.Slowloris.Interval
+}
+
+// holdSlowloris keeps conn open, writing to it every interval, until the
+// server closes it or ctx is done, and returns how long it was open.
+func (s *Stress) holdSlowloris(ctx context.Context, conn net.Conn, target *url.URL) time.Duration {
+	defer conn.Close()
+
+	start := time.Now()
+	stats := s.Report.Slowloris
+	s.mu.Lock()
+	stats.Connections++
+	stats.open++
+	stats.PeakOpen = max(stats.PeakOpen, stats.open)
+	s.mu.Unlock()
+
+	// The server ends the connection by closing it or by answering early.
+	closed := make(chan int, 1)
+	go func() {
+		status := 0
+		if res, err := http.ReadResponse(bufio.NewReader(conn), nil); err == nil {
+			status = res.StatusCode
+		}
+		closed <- status
+	}()
+
+	ticker := time.NewTicker(s.slowlorisInterval())
+	defer ticker.Stop()
+
+	write := s.slowlorisRequest(target)
+	for line := 0; ; line++ {
+		// A failed write means the server is gone, which the reader sees
+		// too.
+		n, _ := io.WriteString(conn, write)
+		s.mu.Lock()
+		stats.BytesSent += int64(n)
+		s.mu.Unlock()
+		if s.Slowloris.Body {
+			write = "a"
+		} else {
+			write = "X-" + strconv.Itoa(line) + ": " + randomString(8) + "\r\n"
+		}
+
+		select {
+		case <-ticker.C:
+			continue
+		case status := <-closed:
+			s.mu.Lock()
+			stats.open--
+			stats.Closed++
+			held := time.Since(start)
+			stats.held = append(stats.held, held)
+			if status != 0 {
+				if stats.Responses == nil {
+					stats.Responses = make(map[int]int)
+				}
+				stats.Responses[status]++
+			}
+			s.mu.Unlock()
+			return held
+		case <-ctx.Done():
+			s.mu.Lock()
+			stats.open--
+			stats.Open++
+			s.mu.Unlock()
+			return time.Since(start)
+		}
+	}
+}
+
+// validateSlowloris checks the target of a slowloris run, which speaks
+// HTTP/1.1 over its own connections.
+func (s *Stress) validateSlowloris() error {
+	if s.Protocol != ProtocolHTTP1 && s.Protocol != "" {
+		return fmt.Errorf("slowloris only supports %s", ProtocolHTTP1)
+	}
+	target, err := url.Parse(s.URL)
+	if err != nil {
+		return err
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("slowloris needs an http or https URL, not %q", s.URL)
+	}
+	return nil
+}
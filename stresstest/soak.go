@@ -0,0 +1,132 @@
+package stresstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// Snapshot summarizes one interval of a long run. Latencies are in
+// milliseconds and rates in percent or requests per second. The drift fields
+// compare the interval with the first one, so a target that slowly degrades
+// shows a growing P95Drift (in percent) or ErrorRateDrift (in points).
+type Snapshot struct {
+	Elapsed        float64 `json:"elapsed_seconds"`
+	TotalRequests  int     `json:"total_requests"`
+	Requests       int     `json:"requests"`
+	AchievedRate   float64 `json:"achieved_rate"`
+	ErrorRate      float64 `json:"error_rate"`
+	P50            float64 `json:"p50"`
+	P95            float64 `json:"p95"`
+	P99            float64 `json:"p99"`
+	P95Drift       float64 `json:"p95_drift"`
+	ErrorRateDrift float64 `json:"error_rate_drift"`
+}
+
+// WithSnapshots prints a snapshot of the last interval to stderr every
+// interval while the test runs, and appends it as a JSON line to path when
+// path is set. Meant for soak tests that run for hours.
+func (s *Stress) WithSnapshots(interval time.Duration, path string) *Stress {
+	s.SnapshotInterval = interval
+	s.SnapshotFile = path
+	return s
+}
+
+// startSnapshots takes a snapshot every SnapshotInterval until the returned
+// function is called.
+func (s *Stress) startSnapshots() func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	s.mu.Lock()
+	s.Report.keepWindow = true
+	s.mu.Unlock()
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(s.SnapshotInterval)
+		defer ticker.Stop()
+
+		start := time.Now()
+		lastRequests, lastFailed := 0, 0
+		var first *Snapshot
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			s.mu.Lock()
+			requests := s.Report.Requests
+			failed := s.Report.Failed + s.Report.TimedOut
+			window := s.Report.window
+			s.Report.window = nil
+			s.mu.Unlock()
+
+			snapshot := Snapshot{
+				Elapsed:       time.Since(start).Seconds(),
+				TotalRequests: requests,
+				Requests:      requests - lastRequests,
+				AchievedRate:  float64(requests-lastRequests) / s.SnapshotInterval.Seconds(),
+			}
+			if snapshot.Requests > 0 {
+				snapshot.ErrorRate = float64(failed-lastFailed) / float64(snapshot.Requests) * 100
+			}
+			if len(window) > 0 {
+				slices.Sort(window)
+				snapshot.P50 = percentile(window, 50)
+				snapshot.P95 = percentile(window, 95)
+				snapshot.P99 = percentile(window, 99)
+			}
+			lastRequests, lastFailed = requests, failed
+
+			if first == nil {
+				first = &snapshot
+			}
+			if first.P95 > 0 {
+				snapshot.P95Drift = (snapshot.P95 - first.P95) / first.P95 * 100
+			}
+			snapshot.ErrorRateDrift = snapshot.ErrorRate - first.ErrorRate
+
+			s.mu.Lock()
+			s.Report.Snapshots = append(s.Report.Snapshots, snapshot)
+			s.mu.Unlock()
+
+			// The dashboard owns the screen, so under it snapshots are only
+			// recorded.
+			if !s.Dashboard {
+				if s.Progress {
+					// Clear the progress line the snapshot is printed over.
+					fmt.Fprint(s.stderr(), "\r\033[K")
+				}
+				fmt.Fprintf(s.stderr(), "[snapshot %s] %d requests, %.1f req/s, %.2f%% errors, p95 %.2f ms (drift %+.1f%%)\n",
+					time.Duration(snapshot.Elapsed*float64(time.Second)).Round(time.Second), snapshot.Requests,
+					snapshot.AchievedRate, snapshot.ErrorRate, snapshot.P95, snapshot.P95Drift)
+			}
+			if s.snapshotOut != nil {
+				line, _ := json.Marshal(snapshot)
+				fmt.Fprintln(s.snapshotOut, string(line))
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func (r *StressReport) writeSnapshots(w io.Writer) {
+	if len(r.Snapshots) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "--- Snapshots ---")
+	for _, snapshot := range r.Snapshots {
+		fmt.Fprintf(w, "%.0fs: %d requests, %.2f%% errors, p95 %.2f ms (drift %+.1f%%, errors %+.2f)\n",
+			snapshot.Elapsed, snapshot.Requests, snapshot.ErrorRate, snapshot.P95, snapshot.P95Drift, snapshot.ErrorRateDrift)
+	}
+}
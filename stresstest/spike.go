@@ -0,0 +1,122 @@
+package stresstest
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// recoveryTolerance is how much slower than the baseline a second can be and
+// still count as recovered.
+const recoveryTolerance = 1.25
+
+// SpikeProfile alternates a baseline rate with sudden spikes. The test starts
+// and ends at the baseline, with Spikes spikes in between.
+type SpikeProfile struct {
+	BaselineRate     float64
+	BaselineDuration time.Duration
+	SpikeRate        float64
+	SpikeDuration    time.Duration
+	Spikes           int
+}
+
+// SpikeReport compares the load before, during and after one spike. Latencies
+// are in milliseconds and error rates in percent. RecoverySeconds is how long
+// after the spike the average latency and error rate got back near the
+// baseline, or -1 if they did not before the next spike.
+type SpikeReport struct {
+	Spike             int     `json:"spike"`
+	BaselineP95       float64 `json:"baseline_p95"`
+	SpikeP95          float64 `json:"spike_p95"`
+	AfterP95          float64 `json:"after_p95"`
+	BaselineErrorRate float64 `json:"baseline_error_rate"`
+	SpikeErrorRate    float64 `json:"spike_error_rate"`
+	AfterErrorRate    float64 `json:"after_error_rate"`
+	RecoverySeconds   int     `json:"recovery_seconds"`
+}
+
+// WithSpikes runs a spike test: the profile is turned into stages, so each
+// baseline and spike period is also reported as a stage.
+func (s *Stress) WithSpikes(p SpikeProfile) *Stress {
+	s.Spike = &p
+	stages := []Stage{{Duration: p.BaselineDuration, Target: p.BaselineRate, Jump: true}}
+	for i := 0; i < p.Spikes; i++ {
+		stages = append(stages,
+			Stage{Duration: p.SpikeDuration, Target: p.SpikeRate, Jump: true},
+			Stage{Duration: p.BaselineDuration, Target: p.BaselineRate, Jump: true},
+		)
+	}
+	return s.WithStages(stages...)
+}
+
+// computeSpikes fills Spikes from the stage reports and the time series.
+func (r *StressReport) computeSpikes(p SpikeProfile) {
+	r.Spikes = nil
+	for i := 0; i < p.Spikes; i++ {
+		if 2*i+2 >= len(r.Stages) {
+			return
+		}
+		before, spike, after := r.Stages[2*i], r.Stages[2*i+1], r.Stages[2*i+2]
+
+		spikeEnd := p.BaselineDuration + time.Duration(i)*(p.SpikeDuration+p.BaselineDuration) + p.SpikeDuration
+		baselineLatency, baselineErrors := r.windowAverages(spikeEnd-p.SpikeDuration-p.BaselineDuration, spikeEnd-p.SpikeDuration)
+
+		report := SpikeReport{
+			Spike:             i + 1,
+			BaselineP95:       before.P95,
+			SpikeP95:          spike.P95,
+			AfterP95:          after.P95,
+			BaselineErrorRate: errorRate(before.StressReport),
+			SpikeErrorRate:    errorRate(spike.StressReport),
+			AfterErrorRate:    errorRate(after.StressReport),
+			RecoverySeconds:   -1,
+		}
+		first := int((spikeEnd + time.Second - 1) / time.Second)
+		last := int((spikeEnd + p.BaselineDuration) / time.Second)
+		for second := first; second < last && second < len(r.TimeSeries); second++ {
+			point := r.TimeSeries[second]
+			if point.Requests > 0 && point.AverageLatency <= baselineLatency*recoveryTolerance && point.ErrorRate <= baselineErrors+1 {
+				report.RecoverySeconds = second - first
+				break
+			}
+		}
+		r.Spikes = append(r.Spikes, report)
+	}
+}
+
+// windowAverages returns the average latency and error rate of the seconds
+// of the time series that fall within [from, to).
+func (r *StressReport) windowAverages(from time.Duration, to time.Duration) (float64, float64) {
+	var requests int
+	var latency, failed float64
+	for second := int(from / time.Second); second < int(to/time.Second) && second < len(r.TimeSeries); second++ {
+		point := r.TimeSeries[second]
+		requests += point.Requests
+		latency += point.AverageLatency * float64(point.Requests)
+		failed += point.ErrorRate / 100 * float64(point.Requests)
+	}
+	if requests == 0 {
+		return 0, 0
+	}
+	return latency / float64(requests), failed / float64(requests) * 100
+}
+
+func errorRate(r *StressReport) float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Failed+r.TimedOut) / float64(r.Requests) * 100
+}
+
+func (r *StressReport) writeSpikes(w io.Writer) {
+	for _, spike := range r.Spikes {
+		fmt.Fprintln(w, "--- Spike", spike.Spike, "---")
+		fmt.Fprintln(w, "P95 before/during/after:", spike.BaselineP95, "/", spike.SpikeP95, "/", spike.AfterP95, "ms")
+		fmt.Fprintln(w, "ErrorRate before/during/after:", spike.BaselineErrorRate, "/", spike.SpikeErrorRate, "/", spike.AfterErrorRate, "%")
+		if spike.RecoverySeconds < 0 {
+			fmt.Fprintln(w, "Recovery: not recovered before the next period")
+		} else {
+			fmt.Fprintln(w, "Recovery:", spike.RecoverySeconds, "s")
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package stresstest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Stage is one step of a load profile. The arrival rate moves linearly from
+// the previous stage's target (zero for the first stage) to Target, in
+// requests per second, over Duration. A stage with the same target as the
+// previous one holds the rate steady, and one with Jump set switches to
+// Target at once.
+type Stage struct {
+	Duration time.Duration `yaml:"duration" json:"duration"`
+	Target   float64       `yaml:"target" json:"target"`
+	Jump     bool          `yaml:"jump" json:"jump,omitempty"`
+}
+
+// StageReport holds the statistics of the requests scheduled during a stage.
+type StageReport struct {
+	Stage    int           `json:"stage"`
+	Duration time.Duration `json:"duration"`
+	Target   float64       `json:"target"`
+	*StressReport
+}
+
+// WithStages runs the stages one after the other in an open model, as
+// WithArrivalRate does for a single rate, and reports each one separately.
+// The test lasts as long as the stages together.
+func (s *Stress) WithStages(stages ...Stage) *Stress {
+	s.Stages = stages
+	return s
+}
+
+// stageAt returns the index of the stage running offset into the test, and
+// the arrival rate at that moment. The index is len(s.Stages) once all the
+// stages are over.
+func (s *Stress) stageAt(offset time.Duration) (int, float64) {
+	from := 0.0
+	for i, stage := range s.Stages {
+		if offset < stage.Duration {
+			if stage.Jump {
+				return i, stage.Target
+			}
+			progress := float64(offset) / float64(stage.Duration)
+			return i, from + (stage.Target-from)*progress
+		}
+		offset -= stage.Duration
+		from = stage.Target
+	}
+	return len(s.Stages), 0
+}
+
+// runStages schedules arrivals at the rate of the current stage until the
+// last stage ends or ctx is done.
+func (s *Stress) runStages(ctx context.Context, wg *sync.WaitGroup) {
+	maxOutstanding := s.MaxOutstanding
+	if maxOutstanding <= 0 {
+		maxOutstanding = s.Concurrency
+	}
+
+	slots := make(chan int, maxOutstanding)
+	for i := 1; i <= maxOutstanding; i++ {
+		slots <- i
+	}
+
+	s.stagesStart = time.Now()
+	s.Report.Stages = make([]*StageReport, len(s.Stages))
+	for i, stage := range s.Stages {
+		s.Report.Stages[i] = &StageReport{Stage: i + 1, Duration: stage.Duration, Target: stage.Target, StressReport: s.Report.subReport()}
+	}
+
+	// The rate changes continuously, so arrivals are accumulated over small
+	// steps and each one is scheduled at the start of the step it falls in.
+	// Steps without arrivals are waited for too, so stages at a rate of zero
+	// last as long as the others and the run ends with the last stage.
+	const step = time.Millisecond
+	var offset time.Duration
+	var due float64
+	for {
+		stage, rate := s.stageAt(offset)
+		scheduled := s.stagesStart.Add(offset)
+		if stage == len(s.Stages) {
+			sleepContext(ctx, time.Until(scheduled))
+			return
+		}
+		if rate == 0 && !sleepContext(ctx, time.Until(scheduled)) {
+			return
+		}
+		due += rate * step.Seconds()
+		offset += step
+
+		for ; due >= 1; due-- {
+			if !sleepContext(ctx, time.Until(scheduled)) {
+				return
+			}
+
+			var slot int
+			select {
+			case slot = <-slots:
+			case <-ctx.Done():
+				return
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { slots <- slot }()
+				s.runIteration(ctx, slot, scheduled)
+			}()
+		}
+	}
+}
+
+// stageReport returns the report of the stage a request was scheduled in, or
+// nil when stages are not used.
+func (s *Stress) stageReport(scheduled time.Time) *StressReport {
+	if len(s.Stages) == 0 || scheduled.IsZero() {
+		return nil
+	}
+	stage, _ := s.stageAt(scheduled.Sub(s.stagesStart))
+	if stage >= len(s.Report.Stages) {
+		return nil
+	}
+	return s.Report.Stages[stage].StressReport
+}
+
+func (r *StressReport) writeStages(w io.Writer) {
+	for _, stage := range r.Stages {
+		fmt.Fprintf(w, "--- Stage %d (%s at %g req/s) ---\n", stage.Stage, stage.Duration, stage.Target)
+		fmt.Fprintln(w, "Requests:", stage.Requests)
+		fmt.Fprintln(w, "Failed:", stage.Failed)
+		fmt.Fprintln(w, "Succeeded:", stage.Succeeded)
+		fmt.Fprintln(w, "TimedOut:", stage.TimedOut)
+		fmt.Fprintln(w, "AchievedRate:", stage.AchievedRate, "req/s")
+		fmt.Fprintln(w, "P50:", stage.P50, "ms")
+		fmt.Fprintln(w, "P95:", stage.P95, "ms")
+		fmt.Fprintln(w, "P99:", stage.P99, "ms")
+	}
+}
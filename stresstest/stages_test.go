@@ -0,0 +1,25 @@
+package stresstest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunStagesWaitsForZeroRateStages(t *testing.T) {
+	s := New("http://stress.test/", WithConcurrency(4))
+	s.WithTransport(&fakeTransport{})
+	s.WithStages(Stage{Duration: 100 * time.Millisecond, Target: 100}, Stage{Duration: 200 * time.Millisecond, Target: 0})
+
+	start := time.Now()
+	report, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("run lasted %s, want the 300ms of the stages", elapsed)
+	}
+	if report.Requests == 0 {
+		t.Error("no request was sent during the first stage")
+	}
+}
@@ -0,0 +1,167 @@
+package stresstest
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// statsdMaxPacket keeps datagrams under the usual Ethernet MTU.
+const statsdMaxPacket = 1432
+
+// statsdFlushInterval bounds how long per-request metrics wait in the buffer.
+const statsdFlushInterval = time.Second
+
+// StatsDConfig says where NewStatsDReporter sends metrics.
+type StatsDConfig struct {
+	// Addr is the host:port of the StatsD or Datadog agent; the port
+	// defaults to 8125.
+	Addr string
+	// Prefix is prepended to every metric name and defaults to "stresstest".
+	Prefix string
+	// Tags are DogStatsD tags such as "env:staging", added to every metric.
+	Tags []string
+	// Interval, when set, sends aggregated metrics once per interval instead
+	// of a counter and a timing for every request.
+	Interval time.Duration
+}
+
+// NewStatsDReporter sends metrics over UDP to a StatsD server or a Datadog
+// agent while the test runs. By default every request adds to the
+// PREFIX.requests counter, failures to PREFIX.failed, and its latency is sent
+// as the PREFIX.latency timing; with an Interval the metrics of each interval
+// are sent as counters and gauges, like NewGraphiteReporter does.
+func NewStatsDReporter(cfg StatsDConfig) (Reporter, error) {
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("statsd: no address")
+	}
+	if _, _, err := net.SplitHostPort(cfg.Addr); err != nil {
+		cfg.Addr = net.JoinHostPort(cfg.Addr, "8125")
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "stresstest"
+	}
+	conn, err := net.Dial("udp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: %w", err)
+	}
+	var tags string
+	if len(cfg.Tags) > 0 {
+		tags = "|#" + strings.Join(cfg.Tags, ",")
+	}
+	r := &statsdReporter{
+		conn:    conn,
+		prefix:  cfg.Prefix,
+		tags:    tags,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	if cfg.Interval > 0 {
+		push := newPushReporter("statsd", cfg.Interval, func(m intervalMetrics) error {
+			for _, field := range m.fields() {
+				kind := "g"
+				if field[0] == "requests" || field[0] == "failed" {
+					kind = "c"
+				}
+				r.add(field[0], field[1], kind)
+			}
+			r.flush()
+			return nil
+		})
+		return &statsdIntervalReporter{pushReporter: push, statsd: r}, nil
+	}
+
+	go r.loop()
+	return r, nil
+}
+
+// statsdReporter buffers metric lines and sends them in datagrams of up to
+// statsdMaxPacket bytes.
+type statsdReporter struct {
+	conn   net.Conn
+	prefix string
+	tags   string
+
+	mu  sync.Mutex
+	buf []byte
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func (r *statsdReporter) loop() {
+	defer close(r.stopped)
+
+	ticker := time.NewTicker(statsdFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			r.flush()
+		}
+	}
+}
+
+func (r *statsdReporter) Collect(sample Sample) {
+	r.add("requests", "1", "c")
+	if sample.Err != nil || sample.CheckErr != nil {
+		r.add("failed", "1", "c")
+	}
+	r.add("latency", fmt.Sprintf("%.3f", milliseconds(sample.Latency)), "ms")
+}
+
+// add buffers one metric line, sending the buffer first when the line would
+// not fit in the datagram.
+func (r *statsdReporter) add(name string, value string, kind string) {
+	line := r.prefix + "." + name + ":" + value + "|" + kind + r.tags + "\n"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.buf)+len(line) > statsdMaxPacket {
+		r.send()
+	}
+	r.buf = append(r.buf, line...)
+}
+
+func (r *statsdReporter) flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.send()
+}
+
+// send writes the buffered lines as one datagram. It must be called with
+// r.mu held. StatsD is fire and forget, so write errors, such as when nothing
+// listens, are ignored rather than failing the run.
+func (r *statsdReporter) send() {
+	if len(r.buf) == 0 {
+		return
+	}
+	// The trailing newline is not part of the last metric.
+	r.conn.Write(r.buf[:len(r.buf)-1])
+	r.buf = r.buf[:0]
+}
+
+func (r *statsdReporter) Finalize(*StressReport) error {
+	close(r.done)
+	<-r.stopped
+	r.flush()
+	return r.conn.Close()
+}
+
+// statsdIntervalReporter sends the aggregated metrics of a pushReporter
+// through a statsdReporter.
+type statsdIntervalReporter struct {
+	*pushReporter
+	statsd *statsdReporter
+}
+
+func (r *statsdIntervalReporter) Finalize(report *StressReport) error {
+	err := r.pushReporter.Finalize(report)
+	r.statsd.conn.Close()
+	return err
+}
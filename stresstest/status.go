@@ -0,0 +1,84 @@
+package stresstest
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// StatusStats summarizes the responses that came back with one status code.
+// Latencies are in milliseconds and Percentage is of all requests.
+type StatusStats struct {
+	Count          int     `json:"count"`
+	Percentage     float64 `json:"percentage"`
+	MinLatency     float64 `json:"min_latency"`
+	AverageLatency float64 `json:"average_latency"`
+	MaxLatency     float64 `json:"max_latency"`
+	latencySum     time.Duration
+}
+
+func (r *StressReport) addStatusLatency(status int, latency time.Duration) {
+	if r.StatusStats == nil {
+		r.StatusStats = make(map[int]*StatusStats)
+	}
+	stats, ok := r.StatusStats[status]
+	if !ok {
+		stats = &StatusStats{MinLatency: milliseconds(latency)}
+		r.StatusStats[status] = stats
+	}
+	ms := milliseconds(latency)
+	stats.Count++
+	stats.latencySum += latency
+	stats.MinLatency = min(stats.MinLatency, ms)
+	stats.MaxLatency = max(stats.MaxLatency, ms)
+}
+
+func (r *StressReport) computeStatusStats() {
+	for _, stats := range r.StatusStats {
+		if stats.Count == 0 {
+			continue
+		}
+		stats.AverageLatency = milliseconds(stats.latencySum / time.Duration(stats.Count))
+		if r.Requests > 0 {
+			stats.Percentage = float64(stats.Count) / float64(r.Requests) * 100
+		}
+	}
+}
+
+// mergeStatusStats adds other's per-status stats to r. The latency sum is
+// rebuilt from the average, since it does not survive JSON encoding.
+func (r *StressReport) mergeStatusStats(other map[int]*StatusStats) {
+	if r.StatusStats == nil {
+		r.StatusStats = make(map[int]*StatusStats)
+	}
+	for status, stats := range other {
+		merged, ok := r.StatusStats[status]
+		if !ok {
+			merged = &StatusStats{MinLatency: stats.MinLatency}
+			r.StatusStats[status] = merged
+		}
+		merged.Count += stats.Count
+		merged.latencySum += time.Duration(stats.AverageLatency * float64(stats.Count) * float64(time.Millisecond))
+		merged.MinLatency = min(merged.MinLatency, stats.MinLatency)
+		merged.MaxLatency = max(merged.MaxLatency, stats.MaxLatency)
+	}
+}
+
+func (r *StressReport) writeStatusStats(w io.Writer) {
+	statuses := make([]int, 0, len(r.StatusStats))
+	for status := range r.StatusStats {
+		statuses = append(statuses, status)
+	}
+	slices.Sort(statuses)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Status\tCount\tPercent\tMin (ms)\tAvg (ms)\tMax (ms)")
+	for _, status := range statuses {
+		stats := r.StatusStats[status]
+		fmt.Fprintf(tw, "%d\t%d\t%.2f %%\t%.2f\t%.2f\t%.2f\n",
+			status, stats.Count, stats.Percentage, stats.MinLatency, stats.AverageLatency, stats.MaxLatency)
+	}
+	tw.Flush()
+}
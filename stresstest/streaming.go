@@ -0,0 +1,76 @@
+package stresstest
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// bodyRateTicks is how many chunks a second a throttled body is sent in.
+const bodyRateTicks = 10
+
+var errBodyClosed = errors.New("request body closed")
+
+// WithBodyRate streams request bodies at about bytesPerSecond, in chunks sent
+// ten times a second, instead of all at once. The length of the body is then
+// unknown upfront, so HTTP/1.1 uses chunked transfer encoding, which
+// exercises how servers and proxies deal with slow clients and streaming
+// uploads. The time spent sending the body counts in the latency.
+func (s *Stress) WithBodyRate(bytesPerSecond int) *Stress {
+	s.BodyRate = bytesPerSecond
+	return s
+}
+
+// throttledBody reads from r no faster than rate bytes per second.
+type throttledBody struct {
+	r     io.Reader
+	rate  int
+	chunk int
+	start time.Time
+	sent  int64
+
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newThrottledBody(r io.Reader, rate int) *throttledBody {
+	return &throttledBody{
+		r:      r,
+		rate:   rate,
+		chunk:  max(rate/bodyRateTicks, 1),
+		closed: make(chan struct{}),
+	}
+}
+
+func (t *throttledBody) Read(p []byte) (int, error) {
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+	due := t.start.Add(time.Duration(float64(t.sent) / float64(t.rate) * float64(time.Second)))
+	if wait := time.Until(due); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-t.closed:
+			timer.Stop()
+			return 0, errBodyClosed
+		}
+	}
+
+	if len(p) > t.chunk {
+		p = p[:t.chunk]
+	}
+	n, err := t.r.Read(p)
+	t.sent += int64(n)
+	return n, err
+}
+
+// Close stops a pending Read, for instance when the request is cancelled.
+func (t *throttledBody) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	if closer, ok := t.r.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
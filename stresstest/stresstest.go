@@ -1,204 +1,805 @@
 package stresstest
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-type MapStatusRequests map[int]int
-
-type StressReport struct {
-	Requests            int
-	Failed              int
-	Succeeded           int
-	TimedOut            int
-	TotalTime           float64
-	AverageTime         float64
-	FastestTime         int64
-	SlowestTime         int64
-	PercentageSucceeded float64
-	PercentageFailed    float64
-	PercentageTimedOut  float64
-	StatusRequests      MapStatusRequests
-}
-
-func NewStressReport() *StressReport {
-	return &StressReport{
-		Requests:            0,
-		Failed:              0,
-		Succeeded:           0,
-		TimedOut:            0,
-		TotalTime:           0,
-		AverageTime:         0,
-		FastestTime:         0,
-		SlowestTime:         0,
-		PercentageSucceeded: 0,
-		PercentageFailed:    0,
-		PercentageTimedOut:  0,
-		StatusRequests:      make(MapStatusRequests),
-	}
-}
-
 type IStress interface {
-	Run() error
+	Run(ctx context.Context) (*StressReport, error)
 	PrintReport()
 }
 
 type Stress struct {
-	URL         string
-	Method      string
-	Concurrency int
-	Requests    int
-	Timeout     int
-	Verbose     bool
-	Report      *StressReport
-	VerifyTls   bool
-	mu          sync.Mutex
+	URL                  string
+	Method               string
+	Concurrency          int
+	Requests             int
+	Timeout              time.Duration
+	Timeouts             Timeouts
+	Verbose              bool
+	Report               *StressReport
+	VerifyTls            bool
+	Body                 BodyFunc
+	ContentType          string
+	Headers              http.Header
+	ReportFormat         ReportFormat
+	MaxIdleConnsPerHost  int
+	MaxConnections       int
+	DisableKeepAlives    bool
+	RatePerSecond        float64
+	Duration             time.Duration
+	ThinkTime            time.Duration
+	ThinkTimeMax         time.Duration
+	RampUp               time.Duration
+	ExcludeRampUp        bool
+	HistogramBuckets     []float64
+	MetricsAddr          string
+	reporters            []Reporter
+	sinks                []Reporter
+	Targets              []Target
+	Scenario             *Scenario
+	Progress             bool
+	Dashboard            bool
+	Protocol             Protocol
+	Success              SuccessCriteria
+	Validator            ResponseValidator
+	HeaderAssertions     []HeaderAssertion
+	JSONAssertions       []JSONAssertion
+	Checksum             *Checksum
+	LogFormat            LogFormat
+	Templating           bool
+	Feeder               *Feeder
+	FeederPerUser        bool
+	DNSMode              DNSMode
+	DNSServer            string
+	IPFamily             IPFamily
+	ConnectTo            map[string]string
+	LocalAddr            string
+	UnixSocket           string
+	Preflight            bool
+	PreflightProbe       bool
+	GracePeriod          time.Duration
+	MaxSamples           int
+	TracePropagation     bool
+	RequestIDHeader      string
+	Prewarm              int
+	DisableRedirects     bool
+	MaxRedirects         int
+	FailureBodySize      int
+	MaxFailureBodies     int
+	AcceptEncoding       []string
+	DisableDecompression bool
+	BodyRate             int
+	Slowloris            *SlowlorisConfig
+	ConnectFlood         *ConnectFloodConfig
+	Call                 CallFunc
+	Transport            http.RoundTripper
+	Clock                Clock
+	Thresholds           Thresholds
+	Retry                RetryPolicy
+	Stages               []Stage
+	Spike                *SpikeProfile
+	SnapshotInterval     time.Duration
+	SnapshotFile         string
+	snapshotOut          io.Writer
+	out                  io.Writer
+	errOut               io.Writer
+	stagesStart          time.Time
+	ArrivalRate          float64
+	MaxOutstanding       int
+	logger               *requestLogger
+	sequence             atomic.Int64
+	SamplesCSV           string
+	measureFrom          time.Time
+	limiter              *tokenBucket
+	lastErr              error
+	CookieJar            bool
+	Proxy                string
+	TLSClientCert        string
+	TLSClientKey         string
+	TLSCACert            string
+	TLSMinVersion        uint16
+	TLSMaxVersion        uint16
+	TLSCipherSuites      []uint16
+	TLSSessionCacheSize  int
+	sessionCache         tls.ClientSessionCache
+	sessionCacheOnce     sync.Once
+	auth                 authenticator
+	beforeRequest        []BeforeRequest
+	client               *http.Client
+	users                sync.Map
+	inFlight             context.Context
+	abort                context.CancelFunc
+	mu                   sync.Mutex
 }
 
+// NewStress creates a stress test from positional arguments.
+//
+// The timeout is in seconds.
+//
+// Deprecated: use New with options, e.g. New(url, WithMethod(method),
+// WithConcurrency(concurrency), WithRequests(requests)).
 func NewStress(url string, method string, concurrency int, requests int, timeout int, verifyTls bool, verbose bool) *Stress {
-	report := NewStressReport()
-	return &Stress{
-		URL:         url,
-		Method:      method,
-		Concurrency: concurrency,
-		Requests:    requests,
-		Timeout:     timeout,
-		Verbose:     verbose,
-		Report:      report,
-		VerifyTls:   verifyTls,
-		mu:          sync.Mutex{},
-	}
-}
-
-func (s *Stress) Run() error {
-	fmt.Println("Running stress test...")
-	s.run()
+	return New(url,
+		WithMethod(method),
+		WithConcurrency(concurrency),
+		WithRequests(requests),
+		WithTimeout(time.Duration(timeout)*time.Second),
+		WithVerifyTLS(verifyTls),
+		WithVerbose(verbose),
+	)
+}
+
+func (s *Stress) WithBody(body BodyFunc) *Stress {
+	s.Body = body
+	return s
+}
+
+func (s *Stress) WithContentType(contentType string) *Stress {
+	s.ContentType = contentType
+	return s
+}
+
+// WithHeader adds a value to the given header. Calling it more than once with
+// the same key sends the header with multiple values.
+func (s *Stress) WithHeader(key string, value string) *Stress {
+	if s.Headers == nil {
+		s.Headers = make(http.Header)
+	}
+	s.Headers.Add(key, value)
+	return s
+}
+
+func (s *Stress) WithReportFormat(format ReportFormat) *Stress {
+	s.ReportFormat = format
+	return s
+}
+
+// WithMaxIdleConnsPerHost sets how many idle connections the shared client
+// keeps per host. It defaults to the concurrency level.
+func (s *Stress) WithMaxIdleConnsPerHost(n int) *Stress {
+	s.MaxIdleConnsPerHost = n
+	return s
+}
+
+// WithDisableKeepAlives makes every request open a new connection.
+func (s *Stress) WithDisableKeepAlives(disable bool) *Stress {
+	s.DisableKeepAlives = disable
+	return s
+}
+
+// WithRatePerSecond caps the number of requests started per second across all
+// workers. Zero means no limit.
+func (s *Stress) WithRatePerSecond(rate float64) *Stress {
+	s.RatePerSecond = rate
+	return s
+}
+
+// WithDuration runs the test until the given wall-clock time has passed
+// instead of stopping after a fixed number of requests.
+func (s *Stress) WithDuration(duration time.Duration) *Stress {
+	s.Duration = duration
+	return s
+}
+
+// WithRampUp starts workers one by one over the given period instead of all
+// at once. When exclude is true, requests started during the ramp-up are left
+// out of the report statistics; Validate then rejects a duration that is not
+// longer than the ramp-up.
+func (s *Stress) WithRampUp(rampUp time.Duration, exclude bool) *Stress {
+	s.RampUp = rampUp
+	s.ExcludeRampUp = exclude
+	return s
+}
+
+// WithThinkTime pauses each worker between its consecutive iterations, to
+// model users rather than back-to-back requests. The pause is drawn uniformly
+// from [min, max]; pass max <= min for a fixed pause. It is not part of the
+// measured latency.
+func (s *Stress) WithThinkTime(min time.Duration, max time.Duration) *Stress {
+	s.ThinkTime = min
+	s.ThinkTimeMax = max
+	return s
+}
+
+// thinkTime returns the pause to take before a worker's next iteration.
+func (s *Stress) thinkTime() time.Duration {
+	if s.ThinkTimeMax <= s.ThinkTime {
+		return s.ThinkTime
+	}
+	return s.ThinkTime + time.Duration(rand.Int63n(int64(s.ThinkTimeMax-s.ThinkTime)+1))
+}
+
+// WithHistogramBuckets sets the upper bounds, in milliseconds, of the latency
+// histogram buckets.
+func (s *Stress) WithHistogramBuckets(bounds ...float64) *Stress {
+	s.HistogramBuckets = bounds
+	return s
+}
+
+// WithMetricsAddr serves live Prometheus metrics on addr (e.g. ":9090") at
+// /metrics while the test runs.
+func (s *Stress) WithMetricsAddr(addr string) *Stress {
+	s.MetricsAddr = addr
+	return s
+}
+
+// WithSamplesCSV writes every request result to a CSV file at path as the
+// test runs.
+func (s *Stress) WithSamplesCSV(path string) *Stress {
+	s.SamplesCSV = path
+	return s
+}
+
+// WithProgress prints a live progress line to stderr every second while the
+// test runs.
+func (s *Stress) WithProgress(progress bool) *Stress {
+	s.Progress = progress
+	return s
+}
+
+// SetOutput sends what the test prints on stdout, PrintReport and the verbose
+// request lines, to w instead.
+func (s *Stress) SetOutput(w io.Writer) *Stress {
+	s.out = w
+	return s
+}
+
+// SetErrorOutput sends what the test prints on stderr, the progress line, the
+// snapshots and the errors of the reporters that push while the test runs, to
+// w instead.
+func (s *Stress) SetErrorOutput(w io.Writer) *Stress {
+	s.errOut = w
+	return s
+}
+
+func (s *Stress) stdout() io.Writer {
+	if s.out == nil {
+		return os.Stdout
+	}
+	return s.out
+}
+
+func (s *Stress) stderr() io.Writer {
+	if s.errOut == nil {
+		return os.Stderr
+	}
+	return s.errOut
+}
+
+// WithProtocol forces the HTTP protocol version. HTTP/1.1 is the default.
+func (s *Stress) WithProtocol(protocol Protocol) *Stress {
+	s.Protocol = protocol
+	return s
+}
+
+// Run runs the stress test until it completes or ctx is done and returns the
+// report. It prints nothing itself: add a reporter such as NewConsoleReporter
+// to write the report out. On cancellation no new requests are started, the
+// ones in flight are allowed to finish, and the report covers everything sent
+// so far. The report is nil when the test could not start; otherwise it is
+// returned along with any error, such as a *ThresholdError.
+func (s *Stress) Run(ctx context.Context) (*StressReport, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	client, err := s.newClient()
+	if err != nil {
+		return nil, err
+	}
+	s.client = client
+	s.Report.maxSamples = s.MaxSamples
+	if s.Preflight {
+		if err := s.preflight(ctx); err != nil {
+			return nil, err
+		}
+	}
+	s.prewarm(ctx)
+	if s.RatePerSecond > 0 {
+		s.limiter = newTokenBucket(s.RatePerSecond, 1)
+	}
+	if err := s.openSinks(); err != nil {
+		return nil, err
+	}
+	if s.SnapshotFile != "" {
+		f, err := os.Create(s.SnapshotFile)
+		if err != nil {
+			s.finalizeSinks()
+			return nil, fmt.Errorf("snapshot file: %w", err)
+		}
+		defer f.Close()
+		s.snapshotOut = f
+	}
+	if s.Verbose {
+		s.logger = newRequestLogger(s.stdout(), s.LogFormat)
+	}
+	stopInFlight := s.startInFlight(ctx)
+	s.run(ctx)
+	stopInFlight()
+	if s.logger != nil {
+		s.logger.Close()
+	}
+
+	var runErr error
+	if err := ctx.Err(); err != nil {
+		s.Report.Cancelled = true
+		runErr = err
+	} else if s.Report.Requests > 0 && s.Report.Responses()+s.Report.TimedOut == 0 {
+		runErr = fmt.Errorf("none of the %d requests could be sent: %w", s.Report.Requests, s.lastErr)
+	} else if s.Report.Slowloris != nil && s.Report.Slowloris.Connections == 0 {
+		runErr = fmt.Errorf("no slowloris connection could be opened: %w", s.lastErr)
+	} else if s.Report.ConnectFlood != nil && s.Report.ConnectFlood.Connections == 0 {
+		runErr = fmt.Errorf("no connection could be opened: %w", s.lastErr)
+	}
+	thresholdErr := s.Report.Evaluate(s.Thresholds)
+	return s.Report, errors.Join(runErr, thresholdErr, s.finalizeSinks())
+}
+
+// RunContext runs the stress test like Run, leaving the report in s.Report.
+//
+// Deprecated: use Run, which returns the report.
+func (s *Stress) RunContext(ctx context.Context) error {
+	_, err := s.Run(ctx)
+	return err
+}
+
+// openSinks collects the reporters for this run: the registered ones plus
+// those implied by MetricsAddr and SamplesCSV.
+func (s *Stress) openSinks() error {
+	s.sinks = append([]Reporter(nil), s.reporters...)
+	for _, r := range s.sinks {
+		if w, ok := r.(errorWriter); ok {
+			w.setErrorOutput(s.stderr())
+		}
+	}
+	if s.MetricsAddr != "" {
+		metrics, err := NewPrometheusReporter(s.MetricsAddr)
+		if err != nil {
+			s.finalizeSinks()
+			return fmt.Errorf("metrics endpoint: %w", err)
+		}
+		s.sinks = append(s.sinks, metrics)
+	}
+	if s.SamplesCSV != "" {
+		samples, err := NewCSVReporter(s.SamplesCSV)
+		if err != nil {
+			s.finalizeSinks()
+			return fmt.Errorf("samples csv: %w", err)
+		}
+		s.sinks = append(s.sinks, samples)
+	}
 	return nil
 }
 
+func (s *Stress) finalizeSinks() error {
+	var errs []error
+	for _, r := range s.sinks {
+		errs = append(errs, r.Finalize(s.Report))
+	}
+	return errors.Join(errs...)
+}
+
 func (s *Stress) PrintReport() {
-	fmt.Println("--- Report ---")
-	fmt.Println("Requests:", s.Report.Requests)
-	fmt.Println("Failed:", s.Report.Failed)
-	fmt.Println("Succeeded:", s.Report.Succeeded)
-	fmt.Println("TimedOut:", s.Report.TimedOut)
-	fmt.Println("TotalTime:", s.Report.TotalTime, "ms")
-	fmt.Println("AverageTime:", s.Report.AverageTime, "ms")
-	fmt.Println("FastestTime:", s.Report.FastestTime, "ms")
-	fmt.Println("SlowestTime:", s.Report.SlowestTime, "ms")
-	fmt.Println("PercentageSucceeded:", s.Report.PercentageSucceeded, "%")
-	fmt.Println("PercentageFailed:", s.Report.PercentageFailed, "%")
-	fmt.Println("PercentageTimedOut:", s.Report.PercentageTimedOut, "%")
-	fmt.Println("--- Requests per status code ---")
-	for status, requests := range s.Report.StatusRequests {
-		fmt.Println("Status", fmt.Sprint(status)+":", requests, "requests")
-	}
-}
-
-func (s *Stress) run() {
-	start := time.Now()
+	if err := s.WriteReport(s.stdout()); err != nil {
+		fmt.Fprintln(s.stderr(), err)
+	}
+}
+
+// WriteReport writes the report to w using the configured ReportFormat.
+func (s *Stress) WriteReport(w io.Writer) error {
+	return writeReport(w, s.Report, s.ReportFormat)
+}
+
+func (s *Stress) run(ctx context.Context) {
+	start := s.now()
+	s.measureFrom = start
+	if s.ExcludeRampUp {
+		s.measureFrom = start.Add(s.RampUp)
+	}
+
+	stopProgress := func() {}
+	switch {
+	case s.Dashboard:
+		stopProgress = s.startDashboard()
+	case s.Progress:
+		stopProgress = s.startProgress()
+	}
+	stopSnapshots := func() {}
+	if s.SnapshotInterval > 0 {
+		stopSnapshots = s.startSnapshots()
+	}
 
 	var wg sync.WaitGroup
 
-	for i := 0; i < s.Concurrency; i++ {
+	runCtx := ctx
+	if s.Duration > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, s.Duration)
+		defer cancel()
+	}
+
+	switch {
+	case s.Protocol == ProtocolWebSocket:
+		s.runWebSocket(runCtx, &wg)
+	case s.Slowloris != nil:
+		s.runSlowloris(runCtx, &wg)
+	case s.ConnectFlood != nil:
+		s.runConnectFlood(runCtx, &wg)
+	case len(s.Stages) > 0:
+		s.runStages(runCtx, &wg)
+	case s.ArrivalRate > 0:
+		s.runOpenModel(runCtx, &wg)
+	case s.Duration > 0:
+		s.runForDuration(runCtx, &wg)
+	default:
+		s.runForRequests(runCtx, &wg)
+	}
+
+	wg.Wait()
+	stopSnapshots()
+	stopProgress()
+	elapsed := s.since(s.measureFrom)
+	if elapsed < 0 {
+		// The run ended before the excluded ramp-up did.
+		elapsed = 0
+	}
+
+	s.Report.RequestedRate = s.RatePerSecond
+	s.Report.finalize(elapsed, s.HistogramBuckets)
+	for _, endpoint := range s.Report.Endpoints {
+		endpoint.finalize(elapsed, s.HistogramBuckets)
+	}
+	for _, stage := range s.Report.Stages {
+		stage.finalize(stage.Duration, s.HistogramBuckets)
+	}
+	if s.Spike != nil {
+		s.Report.computeSpikes(*s.Spike)
+	}
+}
+
+// runForRequests issues exactly Requests iterations. They are queued as slots
+// on a shared channel that the workers drain, so the total does not depend on
+// how Requests divides by Concurrency and no more than Concurrency run at once.
+func (s *Stress) runForRequests(ctx context.Context, wg *sync.WaitGroup) {
+	if s.Requests <= 0 {
+		return
+	}
+	slots := newSlots(s.Requests)
+
+	for i := 0; i < s.Concurrency && i < s.Requests; i++ {
 		wg.Add(1)
 		i := i
 
 		go func() {
 			defer wg.Done()
-			for j := 0; j < s.Requests/s.Concurrency; j++ {
-				s.runRequest(i + 1)
+			if !sleepContext(ctx, s.rampUpDelay(i)) {
+				return
+			}
+			first := true
+			for range slots {
+				if !first && !sleepContext(ctx, s.thinkTime()) {
+					return
+				}
+				first = false
+				s.runIteration(ctx, i+1, time.Time{})
 			}
 		}()
 	}
+}
 
-	for i := 0; i < s.Requests%s.Concurrency; i++ {
+// newSlots returns a closed channel holding n slots, one per unit of work.
+func newSlots(n int) chan struct{} {
+	slots := make(chan struct{}, n)
+	for i := 0; i < n; i++ {
+		slots <- struct{}{}
+	}
+	close(slots)
+	return slots
+}
+
+// runForDuration keeps every worker issuing requests until ctx is done,
+// ignoring the Requests count.
+func (s *Stress) runForDuration(ctx context.Context, wg *sync.WaitGroup) {
+	for i := 0; i < s.Concurrency; i++ {
 		wg.Add(1)
 		i := i
 
 		go func() {
 			defer wg.Done()
-			s.runRequest(i + 1)
+			if !sleepContext(ctx, s.rampUpDelay(i)) {
+				return
+			}
+			for ctx.Err() == nil {
+				s.runIteration(ctx, i+1, time.Time{})
+				sleepContext(ctx, s.thinkTime())
+			}
 		}()
 	}
+}
 
-	wg.Wait()
-	elapsed := time.Since(start).Milliseconds()
+// rampUpDelay spreads worker start times evenly over RampUp, so the first
+// worker starts immediately and the last one when the ramp-up ends.
+func (s *Stress) rampUpDelay(worker int) time.Duration {
+	if s.RampUp <= 0 || s.Concurrency <= 1 {
+		return 0
+	}
+	return s.RampUp * time.Duration(worker) / time.Duration(s.Concurrency-1)
+}
+
+// sleepContext sleeps for d and reports whether it did so without ctx being
+// done.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
 
-	s.Report.TotalTime = float64(elapsed)
-	s.Report.AverageTime = s.Report.TotalTime / float64(s.Report.Requests)
-	s.Report.PercentageSucceeded = float64(s.Report.Succeeded) / float64(s.Report.Requests) * 100
-	s.Report.PercentageFailed = float64(s.Report.Failed) / float64(s.Report.Requests) * 100
-	s.Report.PercentageTimedOut = float64(s.Report.TimedOut) / float64(s.Report.Requests) * 100
-	fmt.Println("Finished stress test")
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
-func (s *Stress) runRequest(concurrencyGroup int) {
-	start := time.Now()
+// runIteration is one unit of work for a worker: either the whole scenario or
+// a single request to one of the targets. scheduled is the intended start in
+// the open model and zero otherwise; scenarios don't use it.
+func (s *Stress) runIteration(ctx context.Context, worker int, scheduled time.Time) {
+	if s.Scenario != nil {
+		s.runScenario(ctx, worker)
+		return
+	}
+	spec := s.targetSpec(s.pickTarget())
+	spec.Scheduled = scheduled
+	if s.Call != nil {
+		s.runCall(ctx, worker, spec)
+		return
+	}
+	s.runRequest(ctx, worker, spec)
+}
 
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: !s.VerifyTls},
+// runRequest sends a single request. ctx only gates the rate limiter: a
+// request that has started is never aborted, so cancellation drains cleanly.
+func (s *Stress) runRequest(ctx context.Context, concurrencyGroup int, spec requestSpec) {
+	if s.limiter != nil && spec.Scheduled.IsZero() {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return
+		}
 	}
 
-	client := &http.Client{
-		Timeout:   time.Duration(s.Timeout) * time.Second,
-		Transport: tr,
+	for attempt := 0; ; attempt++ {
+		if !s.attempt(ctx, concurrencyGroup, spec, attempt) {
+			return
+		}
+		s.mu.Lock()
+		s.Report.Retries++
+		s.mu.Unlock()
 	}
+}
+
+// attempt makes one try at the request of spec and reports whether it should
+// be retried. Otherwise it has been recorded as the outcome of the request.
+func (s *Stress) attempt(ctx context.Context, concurrencyGroup int, spec requestSpec, attempt int) bool {
+	start := s.now()
 
-	req, err := http.NewRequest(s.Method, s.URL, nil)
+	vu := s.virtualUser(concurrencyGroup)
+	req, err := s.newRequest(vu, spec, attempt)
 	if err != nil {
-		panic(err)
+		if s.logger != nil {
+			s.logger.log(concurrencyGroup, spec.Method, spec.URL, "", nil, err, nil, 0, s.sequence.Add(1), requestTimings{})
+		}
+		s.collect(Sample{Start: start, Worker: concurrencyGroup, Target: spec.Label, Method: spec.Method, URL: spec.URL, Err: err})
+		s.updateReport(spec, nil, &requestError{err: err}, nil, 0)
+		return false
 	}
 
-	res, err := client.Do(req)
+	// The trace goes on top of the in-flight context, which WithContext would
+	// otherwise replace.
+	req, chain := withRedirectChain(req.WithContext(s.inFlight))
+	req, trace := withTrace(req)
+	s.addInFlight(1)
+	res, err := vu.client.Do(req)
 
-	elapsed := time.Since(start).Milliseconds()
+	elapsed := s.since(start)
+	var corrected time.Duration
+	if !spec.Scheduled.IsZero() {
+		corrected = time.Since(spec.Scheduled)
+	}
+	s.addInFlight(-1)
 
-	if s.Verbose {
-		fmt.Print(fmt.Sprint(concurrencyGroup) + " | " + fmt.Sprint(s.Report.Requests+1) + " " + s.Method + " " + s.URL)
-		fmt.Println(" Time:", elapsed, "ms, Status:", res.StatusCode)
+	var checkErr error
+	var received int64
+	var failure *FailureBody
+	var size bodySize
+	var jsonResults []jsonResult
+	if err == nil {
+		var body []byte
+		var readErr error
+		body, size, readErr = s.readBody(res, spec)
+		received = size.wire
+		if readErr != nil {
+			err = readErr
+		} else {
+			checkErr = s.validate(spec, res, body, elapsed)
+		}
+		if c := s.checksum(spec); c != nil && err == nil && checkErr == nil && hasBody(res) {
+			checkErr = c.check(size.sum)
+		}
+		if err == nil && checkErr == nil {
+			jsonResults, checkErr = s.checkJSON(spec, body)
+		}
+		if checkErr != nil && s.FailureBodySize > 0 {
+			failure = &FailureBody{Method: req.Method, URL: req.URL.String(), Status: res.StatusCode, Error: checkErr.Error()}
+			failure.Body, failure.Truncated = s.failureBody(body, size.decoded)
+		}
+	}
+	timings := trace.finish()
+	if s.aborted(err) {
+		return false
 	}
 
-	s.updateReport(res, err, elapsed)
-}
+	if s.logger != nil {
+		logErr := err
+		if logErr == nil {
+			logErr = checkErr
+		}
+		s.logger.log(concurrencyGroup, req.Method, req.URL.String(), s.requestID(req), res, logErr, failure, elapsed, s.sequence.Add(1), timings)
+	}
+
+	if attempt < s.Retry.Attempts && s.Retry.retryable(res, err) && sleepContext(ctx, s.Retry.backoff(attempt)) {
+		return true
+	}
+
+	sample := Sample{Start: start, Worker: concurrencyGroup, Target: spec.Label, Method: req.Method, URL: req.URL.String(), Latency: elapsed, Err: err, CheckErr: checkErr, TraceParent: req.Header.Get("traceparent"), RequestID: s.requestID(req)}
+	if res != nil {
+		sample.Status = res.StatusCode
+	}
+	s.collect(sample)
+
+	if s.ExcludeRampUp && start.Before(s.measureFrom) {
+		s.mu.Lock()
+		s.Report.WarmUpRequests++
+		s.mu.Unlock()
+		return false
+	}
 
-func (s *Stress) updateReport(res *http.Response, err error, elapsed int64) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	if corrected > 0 {
+		s.Report.addCorrectedLatency(corrected)
+	}
+	s.Report.Phases.add(timings)
+	s.Report.Connections.add(timings)
+	if timings.GotConn && !timings.Reused {
+		s.Report.addNewConnection(s.since(s.measureFrom))
+	}
+	s.Report.Redirects.add(chain)
+	s.Report.BytesReceived += received
+	if res != nil {
+		s.Report.HeaderBytesReceived += headerSize(res)
+		s.Report.addServerTiming(res, elapsed)
+	}
+	if res != nil && hasBody(res) && s.compressionEnabled() {
+		s.Report.Compression.add(size)
+	}
+	if failure != nil {
+		s.Report.addFailureBody(*failure, s.MaxFailureBodies)
+	}
+	s.Report.addJSONResults(jsonResults)
+	if attempt > 0 && err == nil && checkErr == nil {
+		s.Report.SucceededAfterRetry++
+	}
+	s.mu.Unlock()
 
-	if err != nil {
-		fmt.Println(err)
-		if strings.Contains(err.Error(), "connection refused") {
-			panic(err)
+	s.updateReport(spec, res, err, checkErr, elapsed)
+	return false
+}
+
+func (s *Stress) newRequest(vu *VirtualUser, spec requestSpec, attempt int) (*http.Request, error) {
+	if s.Templating {
+		// Stress-level headers are templated too, so fold them into the spec.
+		headers := s.Headers.Clone()
+		if headers == nil {
+			headers = make(http.Header)
 		}
-		if err.Error() == http.ErrHandlerTimeout.Error() {
-			s.Report.TimedOut++
+		for key, values := range spec.Headers {
+			headers[http.CanonicalHeaderKey(key)] = values
 		}
-		s.Report.Failed++
-	} else {
-		if res.StatusCode != 200 {
-			s.Report.Failed++
-		} else {
-			s.Report.Succeeded++
+		spec.Headers = headers
+
+		var err error
+		spec, err = s.expandSpec(vu, spec, s.templateData(vu))
+		if err != nil {
+			return nil, err
 		}
-		if _, ok := s.Report.StatusRequests[res.StatusCode]; !ok {
-			s.Report.StatusRequests[res.StatusCode] = 0
+	}
+
+	var body io.Reader
+	if spec.Body != nil {
+		b, err := spec.Body()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+		if s.BodyRate > 0 {
+			body = newThrottledBody(b, s.BodyRate)
 		}
-		s.Report.StatusRequests[res.StatusCode]++
 	}
 
-	s.Report.Requests++
+	req, err := http.NewRequest(spec.Method, spec.URL, body)
+	if err != nil {
+		return nil, err
+	}
 
-	if elapsed < s.Report.FastestTime || s.Report.FastestTime == 0 {
-		s.Report.FastestTime = elapsed
+	for _, headers := range []http.Header{s.Headers, spec.Headers} {
+		for key, values := range headers {
+			if http.CanonicalHeaderKey(key) == "Host" {
+				req.Host = values[0]
+				continue
+			}
+			req.Header.Del(key)
+			for _, value := range values {
+				req.Header.Add(key, value)
+			}
+		}
+	}
+
+	if spec.ContentType != "" {
+		req.Header.Set("Content-Type", spec.ContentType)
+	}
+
+	if s.TracePropagation {
+		req.Header.Set("traceparent", newTraceParent())
+	}
+	if s.RequestIDHeader != "" {
+		req.Header.Set(s.RequestIDHeader, newRequestID())
+	}
+	if len(s.AcceptEncoding) > 0 && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", strings.Join(s.AcceptEncoding, ", "))
+	}
+
+	if s.auth != nil {
+		if err := s.auth.apply(req); err != nil {
+			return nil, err
+		}
+	}
+
+	rc := RequestContext{VU: vu.ID, Target: spec.Label, Attempt: attempt}
+	for _, hook := range s.beforeRequest {
+		if err := hook(req, rc); err != nil {
+			return nil, err
+		}
 	}
 
-	if elapsed > s.Report.SlowestTime {
-		s.Report.SlowestTime = elapsed
+	return req, nil
+}
+
+func (s *Stress) updateReport(spec requestSpec, res *http.Response, err error, checkErr error, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.lastErr = err
+	}
+
+	s.Report.record(res, err, checkErr, latency)
+	s.Report.addToTimeline(s.since(s.measureFrom), latency, err != nil || checkErr != nil)
+	if len(s.Targets) > 0 || s.Scenario != nil {
+		s.Report.endpointReport(spec.Endpoint).record(res, err, checkErr, latency)
+	}
+	if stage := s.stageReport(spec.Scheduled); stage != nil {
+		stage.record(res, err, checkErr, latency)
 	}
 }
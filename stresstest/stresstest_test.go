@@ -0,0 +1,53 @@
+package stresstest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRunForRequestsSendsExactCount(t *testing.T) {
+	tests := []struct {
+		requests    int
+		concurrency int
+	}{
+		{requests: 10, concurrency: 50},
+		{requests: 7, concurrency: 3},
+		{requests: 100, concurrency: 8},
+		{requests: 0, concurrency: 4},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d requests %d workers", tt.requests, tt.concurrency), func(t *testing.T) {
+			transport := &fakeTransport{delay: time.Millisecond}
+			s := New("http://stress.test/", WithConcurrency(tt.concurrency), WithRequests(tt.requests))
+			s.WithTransport(transport)
+
+			report, err := s.Run(context.Background())
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if report.Requests != tt.requests {
+				t.Errorf("report has %d requests, want %d", report.Requests, tt.requests)
+			}
+			if calls := int(transport.calls.Load()); calls != tt.requests {
+				t.Errorf("transport got %d requests, want %d", calls, tt.requests)
+			}
+			if peak := int(transport.peak.Load()); peak > tt.concurrency {
+				t.Errorf("%d requests were in flight at once, above the concurrency of %d", peak, tt.concurrency)
+			}
+		})
+	}
+}
+
+func TestNewSlots(t *testing.T) {
+	for _, n := range []int{0, 1, 7} {
+		got := 0
+		for range newSlots(n) {
+			got++
+		}
+		if got != n {
+			t.Errorf("newSlots(%d) holds %d slots", n, got)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package stresstest
+
+import (
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SuccessCriteria decides whether a response counts as succeeded. With no
+// status ranges only 200 is accepted, and also 204 and 304 for HEAD and 204
+// for OPTIONS; the body and latency checks are skipped when unset. The body
+// check is skipped too for responses without a body, such as those to HEAD.
+type SuccessCriteria struct {
+	StatusCodes []StatusRange
+	BodyRegex   *regexp.Regexp
+	MaxLatency  time.Duration
+}
+
+// StatusRange is an inclusive range of status codes.
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// ParseStatusRanges parses specs such as "200", "2xx" or "200-299".
+func ParseStatusRanges(specs ...string) ([]StatusRange, error) {
+	ranges := make([]StatusRange, 0, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+
+		if len(spec) == 3 && strings.HasSuffix(strings.ToLower(spec), "xx") {
+			class, err := strconv.Atoi(spec[:1])
+			if err != nil || class < 1 || class > 5 {
+				return nil, fmt.Errorf("invalid status class %q", spec)
+			}
+			ranges = append(ranges, StatusRange{Min: class * 100, Max: class*100 + 99})
+			continue
+		}
+
+		if from, to, ok := strings.Cut(spec, "-"); ok {
+			min, errMin := strconv.Atoi(from)
+			max, errMax := strconv.Atoi(to)
+			if errMin != nil || errMax != nil || min > max {
+				return nil, fmt.Errorf("invalid status range %q", spec)
+			}
+			ranges = append(ranges, StatusRange{Min: min, Max: max})
+			continue
+		}
+
+		code, err := strconv.Atoi(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid status code %q", spec)
+		}
+		ranges = append(ranges, StatusRange{Min: code, Max: code})
+	}
+	return ranges, nil
+}
+
+// WithSuccessCriteria changes what counts as a succeeded request.
+func (s *Stress) WithSuccessCriteria(criteria SuccessCriteria) *Stress {
+	s.Success = criteria
+	return s
+}
+
+func (c SuccessCriteria) needsBody() bool {
+	return c.BodyRegex != nil
+}
+
+// check returns why the response doesn't meet the criteria, or nil.
+func (c SuccessCriteria) check(res *http.Response, body []byte, latency time.Duration) error {
+	method := ""
+	if res.Request != nil {
+		method = res.Request.Method
+	}
+	accepted := c.acceptsStatus(method, res.StatusCode)
+	if !accepted && res.StatusCode/100 == 3 {
+		return &checkError{kind: ErrorKindRedirect, err: fmt.Errorf("unexpected redirect %d to %s", res.StatusCode, res.Header.Get("Location"))}
+	}
+	if !accepted {
+		return &checkError{kind: ErrorKindStatus, err: fmt.Errorf("unexpected status %d", res.StatusCode)}
+	}
+	if c.BodyRegex != nil && hasBody(res) && !c.BodyRegex.Match(body) {
+		return &checkError{kind: ErrorKindValidation, err: fmt.Errorf("body does not match %q", c.BodyRegex)}
+	}
+	if c.MaxLatency > 0 && latency > c.MaxLatency {
+		return &checkError{kind: ErrorKindSlowResponse, err: fmt.Errorf("latency %s above %s", latency, c.MaxLatency)}
+	}
+	return nil
+}
+
+func (c SuccessCriteria) acceptsStatus(method string, code int) bool {
+	if len(c.StatusCodes) == 0 {
+		return defaultAccepts(method, code)
+	}
+	for _, r := range c.StatusCodes {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// readBody returns the response body when the success criteria, the validator
+// or the JSON assertions need it, or only its first FailureBodySize bytes when
+// failure bodies are captured, and drains the rest, so the connection can go
+// back to the pool either way. Compressed bodies are decoded first, see
+// decodeBody. It also returns the size of the body, and its hash when a
+// checksum is expected. Responses that cannot have a body are not read,
+// whatever their Content-Encoding says.
+func (s *Stress) readBody(res *http.Response, spec requestSpec) ([]byte, bodySize, error) {
+	defer res.Body.Close()
+	if !hasBody(res) {
+		return nil, bodySize{}, nil
+	}
+
+	wire := &countingReader{r: res.Body}
+	reader, decoded, err := s.decodeBody(res, wire)
+	if err != nil {
+		return nil, bodySize{}, err
+	}
+	size := bodySize{encoding: contentEncoding(res), raw: !decoded}
+	var checksum hash.Hash
+	if c := s.checksum(spec); c != nil {
+		checksum = c.newHash()
+		reader = io.TeeReader(reader, checksum)
+	}
+
+	var body []byte
+	switch {
+	case s.Success.needsBody() || s.Validator != nil || len(s.JSONAssertions) > 0 || len(spec.JSONAssertions) > 0:
+		body, err = io.ReadAll(reader)
+		size.decoded = int64(len(body))
+	case s.FailureBodySize > 0:
+		body, size.decoded, err = readBodyHead(reader, s.FailureBodySize)
+	default:
+		size.decoded, err = io.Copy(io.Discard, reader)
+	}
+	size.wire = wire.n
+	if checksum != nil {
+		size.sum = checksum.Sum(nil)
+	}
+	return body, size, err
+}
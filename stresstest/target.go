@@ -0,0 +1,86 @@
+package stresstest
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Target is one URL of a mixed-traffic run. Requests are spread across
+// targets in proportion to their Weight; a zero weight counts as 1. An empty
+// Method uses the method of the test. ExpectStatus, when set, replaces the
+// statuses of the success criteria for this target, for instance 201 for a
+// POST that creates a resource or 404 for a negative test. JSONAssertions are
+// checked on its responses on top of those of the test, and Checksum replaces
+// the checksum of the test.
+type Target struct {
+	URL            string          `json:"url"`
+	Weight         int             `json:"weight"`
+	Method         string          `json:"method,omitempty"`
+	ExpectStatus   []StatusRange   `json:"expect_status,omitempty"`
+	JSONAssertions []JSONAssertion `json:"json_assertions,omitempty"`
+	Checksum       *Checksum       `json:"checksum,omitempty"`
+}
+
+// WithTargets replaces the single URL with several weighted targets. The
+// report then includes a breakdown per endpoint.
+func (s *Stress) WithTargets(targets ...Target) *Stress {
+	s.Targets = targets
+	return s
+}
+
+func (s *Stress) pickTarget() Target {
+	if len(s.Targets) == 0 {
+		return Target{URL: s.URL, Weight: 1}
+	}
+
+	total := 0
+	for _, target := range s.Targets {
+		total += max(target.Weight, 1)
+	}
+
+	n := rand.Intn(total)
+	for _, target := range s.Targets {
+		n -= max(target.Weight, 1)
+		if n < 0 {
+			return target
+		}
+	}
+	return s.Targets[len(s.Targets)-1]
+}
+
+// requestSpec describes a single request to send. Headers are added on top of
+// the ones configured on Stress. Endpoint groups the request in the report.
+type requestSpec struct {
+	Label       string
+	Endpoint    string
+	Method      string
+	URL         string
+	Headers     http.Header
+	Body        BodyFunc
+	ContentType string
+	// ExpectStatus overrides the statuses of the success criteria.
+	ExpectStatus   []StatusRange
+	JSONAssertions []JSONAssertion
+	Checksum       *Checksum
+	// Scheduled is when the open model meant to send the request.
+	Scheduled time.Time
+}
+
+func (s *Stress) targetSpec(target Target) requestSpec {
+	method := target.Method
+	if method == "" {
+		method = s.Method
+	}
+	return requestSpec{
+		Label:          target.URL,
+		Endpoint:       endpointLabel(method, target.URL),
+		Method:         method,
+		URL:            target.URL,
+		Body:           s.Body,
+		ContentType:    s.ContentType,
+		ExpectStatus:   target.ExpectStatus,
+		JSONAssertions: target.JSONAssertions,
+		Checksum:       target.Checksum,
+	}
+}
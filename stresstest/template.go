@@ -0,0 +1,137 @@
+package stresstest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const randStringLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// templateFuncs are available in URLs, header values and bodies when
+// templating is enabled, e.g. {{uuid}} or {{randInt 1 100}}.
+var templateFuncs = template.FuncMap{
+	"uuid": newUUID,
+	"randInt": func(min, max int) int {
+		if max <= min {
+			return min
+		}
+		return min + mathrand.Intn(max-min+1)
+	},
+	"randString": func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = randStringLetters[mathrand.Intn(len(randStringLetters))]
+		}
+		return string(b)
+	},
+	"now": func() string {
+		return time.Now().Format(time.RFC3339)
+	},
+	"timestamp": func() int64 {
+		return time.Now().Unix()
+	},
+}
+
+// templateCache parses each distinct template text once and reuses it for
+// every request.
+type templateCache struct {
+	mu        sync.Mutex
+	templates map[string]*template.Template
+	// funcs are added to templateFuncs, e.g. {{vu}} of a virtual user.
+	funcs template.FuncMap
+}
+
+func (c *templateCache) expand(text string, data any) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	c.mu.Lock()
+	tmpl, ok := c.templates[text]
+	if !ok {
+		var err error
+		tmpl, err = template.New("").Funcs(templateFuncs).Funcs(c.funcs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			c.mu.Unlock()
+			return "", err
+		}
+		if c.templates == nil {
+			c.templates = make(map[string]*template.Template)
+		}
+		c.templates[text] = tmpl
+	}
+	c.mu.Unlock()
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// expandSpec returns a copy of spec with the URL, header values and body
+// templates expanded for one request of vu.
+func (s *Stress) expandSpec(vu *VirtualUser, spec requestSpec, data any) (requestSpec, error) {
+	var err error
+	if spec.URL, err = vu.templates.expand(spec.URL, data); err != nil {
+		return spec, fmt.Errorf("url template: %w", err)
+	}
+
+	if len(spec.Headers) > 0 {
+		headers := make(http.Header, len(spec.Headers))
+		for key, values := range spec.Headers {
+			for _, value := range values {
+				expanded, err := vu.templates.expand(value, data)
+				if err != nil {
+					return spec, fmt.Errorf("header %s template: %w", key, err)
+				}
+				headers[key] = append(headers[key], expanded)
+			}
+		}
+		spec.Headers = headers
+	}
+
+	if spec.Body != nil {
+		body, err := spec.Body()
+		if err != nil {
+			return spec, err
+		}
+		raw, err := io.ReadAll(body)
+		if err != nil {
+			return spec, err
+		}
+		expanded, err := vu.templates.expand(string(raw), data)
+		if err != nil {
+			return spec, fmt.Errorf("body template: %w", err)
+		}
+		spec.Body = BodyFromString(expanded)
+	}
+
+	return spec, nil
+}
+
+// WithTemplating expands template placeholders such as {{uuid}},
+// {{randInt 1 100}} or {{now}} in the URL, headers and body of every request.
+// {{vu}} is the ID of the virtual user sending the request.
+func (s *Stress) WithTemplating(enabled bool) *Stress {
+	s.Templating = enabled
+	return s
+}
+
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,100 @@
+package stresstest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Thresholds are pass/fail limits checked against the report once a run
+// ends. Nil limits are not checked, so zero is a limit like any other: a
+// MaxErrorRate of zero fails the run on the first failed request. A run that
+// completed no request fails as soon as one limit is set.
+type Thresholds struct {
+	// MaxErrorRate is the highest acceptable percentage of requests that
+	// failed or timed out.
+	MaxErrorRate *float64
+	MaxP95       *time.Duration
+	// MinRPS is the lowest acceptable achieved rate, in requests per second.
+	MinRPS *float64
+}
+
+func (t Thresholds) set() bool {
+	return t.MaxErrorRate != nil || t.MaxP95 != nil || t.MinRPS != nil
+}
+
+// ThresholdError lists the thresholds a run did not meet.
+type ThresholdError struct {
+	Violations []string
+}
+
+func (e *ThresholdError) Error() string {
+	return "thresholds not met: " + strings.Join(e.Violations, "; ")
+}
+
+// WithThresholds makes Run fail with a *ThresholdError when the report does
+// not meet t.
+func (s *Stress) WithThresholds(t Thresholds) *Stress {
+	s.Thresholds = t
+	return s
+}
+
+// ThresholdResult is the outcome of one threshold that was checked. Limit
+// and Value are in the unit of the threshold: percent, milliseconds or
+// requests per second.
+type ThresholdResult struct {
+	Name    string  `json:"name"`
+	Limit   float64 `json:"limit"`
+	Value   float64 `json:"value"`
+	Passed  bool    `json:"passed"`
+	Message string  `json:"message"`
+}
+
+// Evaluate checks the report against t, records the result of every checked
+// threshold and the violations in the report and returns them as a
+// *ThresholdError, or nil when every threshold is met.
+func (r *StressReport) Evaluate(t Thresholds) error {
+	var results []ThresholdResult
+	var violations []string
+	check := func(name string, limit, value float64, passed bool, message string) {
+		results = append(results, ThresholdResult{Name: name, Limit: limit, Value: value, Passed: passed, Message: message})
+		if !passed {
+			violations = append(violations, message)
+		}
+	}
+	if t.set() && r.Requests == 0 {
+		check("requests", 1, 0, false, "no request completed")
+	}
+	if t.MaxErrorRate != nil && r.Requests > 0 {
+		maxErrorRate := *t.MaxErrorRate
+		errorRate := float64(r.Failed+r.TimedOut) / float64(r.Requests) * 100
+		message := fmt.Sprintf("error rate %.2f%% above %.2f%%", errorRate, maxErrorRate)
+		if errorRate <= maxErrorRate {
+			message = fmt.Sprintf("error rate %.2f%% within %.2f%%", errorRate, maxErrorRate)
+		}
+		check("max_error_rate", maxErrorRate, errorRate, errorRate <= maxErrorRate, message)
+	}
+	if t.MaxP95 != nil && r.Requests > 0 {
+		limit := milliseconds(*t.MaxP95)
+		message := fmt.Sprintf("p95 %.2f ms above %s", r.P95, *t.MaxP95)
+		if r.P95 <= limit {
+			message = fmt.Sprintf("p95 %.2f ms within %s", r.P95, *t.MaxP95)
+		}
+		check("max_p95", limit, r.P95, r.P95 <= limit, message)
+	}
+	if t.MinRPS != nil {
+		minRPS := *t.MinRPS
+		message := fmt.Sprintf("rate %.2f req/s below %.2f req/s", r.AchievedRate, minRPS)
+		if r.AchievedRate >= minRPS {
+			message = fmt.Sprintf("rate %.2f req/s at least %.2f req/s", r.AchievedRate, minRPS)
+		}
+		check("min_rps", minRPS, r.AchievedRate, r.AchievedRate >= minRPS, message)
+	}
+
+	r.ThresholdResults = results
+	r.ThresholdViolations = violations
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ThresholdError{Violations: violations}
+}
@@ -0,0 +1,41 @@
+package stresstest
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEvaluateThresholds(t *testing.T) {
+	zero := 0.0
+	five := 5.0
+	p95 := 100 * time.Millisecond
+	tests := []struct {
+		name       string
+		thresholds Thresholds
+		requests   int
+		failed     int
+		wantErr    bool
+	}{
+		{name: "none set", thresholds: Thresholds{}, requests: 10, failed: 10},
+		{name: "zero error rate met", thresholds: Thresholds{MaxErrorRate: &zero}, requests: 10},
+		{name: "zero error rate missed", thresholds: Thresholds{MaxErrorRate: &zero}, requests: 10, failed: 1, wantErr: true},
+		{name: "error rate within", thresholds: Thresholds{MaxErrorRate: &five}, requests: 100, failed: 5},
+		{name: "no requests", thresholds: Thresholds{MaxErrorRate: &five}, wantErr: true},
+		{name: "no requests p95", thresholds: Thresholds{MaxP95: &p95}, wantErr: true},
+		{name: "no requests without thresholds", thresholds: Thresholds{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewStressReport()
+			r.Requests = tt.requests
+			r.Failed = tt.failed
+			r.Succeeded = tt.requests - tt.failed
+			err := r.Evaluate(tt.thresholds)
+			var thresholdErr *ThresholdError
+			if tt.wantErr != errors.As(err, &thresholdErr) {
+				t.Errorf("Evaluate = %v, want an error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package stresstest
+
+import "time"
+
+// Timeouts bound the phases of a request separately. Zero values leave a
+// phase bounded only by Timeout, the overall deadline of the request.
+type Timeouts struct {
+	// Connect bounds dialing the TCP connection.
+	Connect time.Duration `json:"connect"`
+	// TLSHandshake bounds the TLS handshake once connected.
+	TLSHandshake time.Duration `json:"tls_handshake"`
+	// ResponseHeader bounds the wait for the response headers once the
+	// request is written. It applies to HTTP/1.1 only.
+	ResponseHeader time.Duration `json:"response_header"`
+}
+
+// WithTimeouts sets timeouts for the phases of every request.
+func (s *Stress) WithTimeouts(t Timeouts) *Stress {
+	s.Timeouts = t
+	return s
+}
+
+func (s *Stress) connectTimeout() time.Duration {
+	if s.Timeouts.Connect > 0 {
+		return s.Timeouts.Connect
+	}
+	return 30 * time.Second
+}
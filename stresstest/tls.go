@@ -0,0 +1,173 @@
+package stresstest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WithClientCertificate presents the certificate in certFile, with the key in
+// keyFile, to servers that require mutual TLS.
+func (s *Stress) WithClientCertificate(certFile string, keyFile string) *Stress {
+	s.TLSClientCert = certFile
+	s.TLSClientKey = keyFile
+	return s
+}
+
+// WithRootCAs verifies servers against the PEM certificates in path, which may
+// be a single bundle or a directory of them. Setting it turns verification on.
+func (s *Stress) WithRootCAs(path string) *Stress {
+	s.TLSCACert = path
+	if path != "" {
+		s.VerifyTls = true
+	}
+	return s
+}
+
+// WithTLSVersions pins the TLS versions offered to servers, such as
+// tls.VersionTLS12; zero keeps the default of crypto/tls for that bound.
+func (s *Stress) WithTLSVersions(min uint16, max uint16) *Stress {
+	s.TLSMinVersion = min
+	s.TLSMaxVersion = max
+	return s
+}
+
+// WithCipherSuites restricts the cipher suites offered to servers. They only
+// apply up to TLS 1.2: crypto/tls doesn't let the TLS 1.3 suites be chosen,
+// so pin the maximum version to 1.2 to test one of them.
+func (s *Stress) WithCipherSuites(ids ...uint16) *Stress {
+	s.TLSCipherSuites = ids
+	return s
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion parses versions such as "1.2" or "TLS1.3".
+func ParseTLSVersion(name string) (uint16, error) {
+	version, ok := tlsVersions[strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "TLS")]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version %q, expected 1.0, 1.1, 1.2 or 1.3", name)
+	}
+	return version, nil
+}
+
+// ParseCipherSuites parses the IANA names of cipher suites, such as
+// TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, insecure ones included.
+func ParseCipherSuites(names ...string) ([]uint16, error) {
+	suites := append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, suite := range suites {
+			if strings.EqualFold(suite.Name, strings.TrimSpace(name)) {
+				ids = append(ids, suite.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown cipher suite %q", name)
+		}
+	}
+	return ids, nil
+}
+
+// WithTLSSessionCache keeps up to size TLS sessions, shared by every
+// connection, so new connections resume a session instead of running a full
+// handshake when the server allows it. Zero, the default of net/http,
+// disables resumption.
+func (s *Stress) WithTLSSessionCache(size int) *Stress {
+	s.TLSSessionCacheSize = size
+	return s
+}
+
+// clientSessionCache returns the session cache of the run, or nil when
+// resumption is disabled. Every TLS config shares it, including those of the
+// WebSocket connections, which are built per connection.
+func (s *Stress) clientSessionCache() tls.ClientSessionCache {
+	if s.TLSSessionCacheSize <= 0 {
+		return nil
+	}
+	s.sessionCacheOnce.Do(func() {
+		s.sessionCache = tls.NewLRUClientSessionCache(s.TLSSessionCacheSize)
+	})
+	return s.sessionCache
+}
+
+func (s *Stress) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: !s.VerifyTls,
+		MinVersion:         s.TLSMinVersion,
+		MaxVersion:         s.TLSMaxVersion,
+		CipherSuites:       s.TLSCipherSuites,
+		ClientSessionCache: s.clientSessionCache(),
+	}
+	if config.MinVersion != 0 && config.MaxVersion != 0 && config.MinVersion > config.MaxVersion {
+		return nil, fmt.Errorf("TLS minimum version %s is above the maximum %s", tls.VersionName(config.MinVersion), tls.VersionName(config.MaxVersion))
+	}
+
+	if s.TLSClientCert != "" || s.TLSClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(s.TLSClientCert, s.TLSClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if s.TLSCACert != "" {
+		pool, err := loadCertPool(s.TLSCACert)
+		if err != nil {
+			return nil, fmt.Errorf("ca bundle: %w", err)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// loadCertPool reads path, or every regular file directly inside it when it
+// is a directory. Files without PEM certificates in a directory are skipped.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	pool := x509.NewCertPool()
+	found := false
+	for _, file := range files {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if pool.AppendCertsFromPEM(pem) {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
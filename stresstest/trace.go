@@ -0,0 +1,242 @@
+package stresstest
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// requestTimings splits the time of one request into its phases. DNS,
+// Connect and TLS are zero when the request reused a pooled connection. QUIC
+// is the handshake of a new HTTP/3 connection, which connects and runs TLS at
+// once, and replaces Connect and TLS.
+type requestTimings struct {
+	DNS      time.Duration
+	Connect  time.Duration
+	TLS      time.Duration
+	QUIC     time.Duration
+	TTFB     time.Duration
+	Transfer time.Duration
+	// GotConn is set once the request has a connection, Reused when it came
+	// from the pool, and Remote is the address it is connected to.
+	GotConn bool
+	Reused  bool
+	Remote  string
+	// Resumed is set when the TLS or QUIC handshake of the new connection
+	// resumed a session.
+	Resumed bool
+}
+
+// requestTrace records the phase timestamps of a request. The hooks can be
+// called from the transport's dialing goroutines, hence the lock.
+type requestTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	firstByte    time.Time
+	timings      requestTimings
+}
+
+type requestTraceKey struct{}
+
+// contextTrace returns the trace of the request ctx belongs to, or nil.
+func contextTrace(ctx context.Context) *requestTrace {
+	t, _ := ctx.Value(requestTraceKey{}).(*requestTrace)
+	return t
+}
+
+// withTrace returns req instrumented with a trace started now.
+func withTrace(req *http.Request) (*http.Request, *requestTrace) {
+	t := &requestTrace{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.timings.DNS = time.Since(t.dnsStart)
+			t.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			t.connectStart = time.Now()
+			t.mu.Unlock()
+		},
+		ConnectDone: func(_ string, _ string, err error) {
+			if err != nil {
+				return
+			}
+			t.mu.Lock()
+			t.timings.Connect = time.Since(t.connectStart)
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, _ error) {
+			t.mu.Lock()
+			t.timings.TLS = time.Since(t.tlsStart)
+			t.timings.Resumed = state.DidResume
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.timings.GotConn = true
+			t.timings.Reused = info.Reused
+			if info.Conn != nil {
+				t.timings.Remote = info.Conn.RemoteAddr().String()
+			}
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.firstByte = time.Now()
+			t.timings.TTFB = t.firstByte.Sub(t.start)
+			t.mu.Unlock()
+		},
+	}
+	ctx := context.WithValue(httptrace.WithClientTrace(req.Context(), trace), requestTraceKey{}, t)
+	return req.WithContext(ctx), t
+}
+
+// quicDialed records the new HTTP/3 connection of the request.
+func (t *requestTrace) quicDialed(dns time.Duration, handshake time.Duration, remote string, resumed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timings.DNS = dns
+	t.timings.QUIC = handshake
+	t.timings.Remote = remote
+	t.timings.Resumed = resumed
+}
+
+// gotQUICResponse records the response headers of an HTTP/3 request, which
+// reused its connection unless it dialed it.
+func (t *requestTrace) gotQUICResponse() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timings.GotConn = true
+	t.timings.Reused = t.timings.QUIC == 0
+	t.firstByte = time.Now()
+	t.timings.TTFB = t.firstByte.Sub(t.start)
+}
+
+// finish marks the end of the body and returns the timings of the request.
+func (t *requestTrace) finish() requestTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.firstByte.IsZero() {
+		t.timings.Transfer = time.Since(t.firstByte)
+	}
+	return t.timings
+}
+
+// PhaseTimings are the average durations, in milliseconds, of the phases of a
+// request. DNS, Connect, TLS and QUIC are averaged over the requests that
+// opened a new connection, TTFB and Transfer over those that got a response.
+type PhaseTimings struct {
+	DNS            float64 `json:"dns"`
+	Connect        float64 `json:"connect"`
+	TLS            float64 `json:"tls"`
+	QUIC           float64 `json:"quic,omitempty"`
+	TTFB           float64 `json:"ttfb"`
+	Transfer       float64 `json:"transfer"`
+	NewConnections int     `json:"new_connections"`
+	sums           requestTimings
+	counts         phaseCounts
+}
+
+// phaseCounts are the numbers of requests each average of PhaseTimings is
+// taken over. Agents send them with their report so that the coordinator
+// can merge the averages.
+type phaseCounts struct {
+	DNS       int `json:"dns"`
+	Connect   int `json:"connect"`
+	TLS       int `json:"tls"`
+	QUIC      int `json:"quic"`
+	Responses int `json:"responses"`
+}
+
+func (p *PhaseTimings) add(t requestTimings) {
+	p.sums.DNS += t.DNS
+	p.sums.Connect += t.Connect
+	p.sums.TLS += t.TLS
+	p.sums.QUIC += t.QUIC
+	p.sums.TTFB += t.TTFB
+	p.sums.Transfer += t.Transfer
+	if t.DNS > 0 {
+		p.counts.DNS++
+	}
+	if t.Connect > 0 {
+		p.counts.Connect++
+		p.NewConnections++
+	}
+	if t.TLS > 0 {
+		p.counts.TLS++
+	}
+	if t.QUIC > 0 {
+		p.counts.QUIC++
+		p.NewConnections++
+	}
+	if t.TTFB > 0 {
+		p.counts.Responses++
+	}
+}
+
+// merge adds the phases of another report, whose sums are rebuilt from its
+// averages and counts.
+func (p *PhaseTimings) merge(other PhaseTimings) {
+	sum := func(average float64, count int) time.Duration {
+		return time.Duration(average * float64(count) * float64(time.Millisecond))
+	}
+	p.sums.DNS += sum(other.DNS, other.counts.DNS)
+	p.sums.Connect += sum(other.Connect, other.counts.Connect)
+	p.sums.TLS += sum(other.TLS, other.counts.TLS)
+	p.sums.QUIC += sum(other.QUIC, other.counts.QUIC)
+	p.sums.TTFB += sum(other.TTFB, other.counts.Responses)
+	p.sums.Transfer += sum(other.Transfer, other.counts.Responses)
+	p.counts.DNS += other.counts.DNS
+	p.counts.Connect += other.counts.Connect
+	p.counts.TLS += other.counts.TLS
+	p.counts.QUIC += other.counts.QUIC
+	p.counts.Responses += other.counts.Responses
+	p.NewConnections += other.NewConnections
+}
+
+func (p *PhaseTimings) finalize() {
+	average := func(sum time.Duration, count int) float64 {
+		if count == 0 {
+			return 0
+		}
+		return milliseconds(sum / time.Duration(count))
+	}
+	p.DNS = average(p.sums.DNS, p.counts.DNS)
+	p.Connect = average(p.sums.Connect, p.counts.Connect)
+	p.TLS = average(p.sums.TLS, p.counts.TLS)
+	p.QUIC = average(p.sums.QUIC, p.counts.QUIC)
+	p.TTFB = average(p.sums.TTFB, p.counts.Responses)
+	p.Transfer = average(p.sums.Transfer, p.counts.Responses)
+}
+
+func (p *PhaseTimings) writeText(w io.Writer) {
+	fmt.Fprintln(w, "--- Timing breakdown (average) ---")
+	fmt.Fprintln(w, "DNS:", p.DNS, "ms")
+	fmt.Fprintln(w, "Connect:", p.Connect, "ms")
+	fmt.Fprintln(w, "TLS:", p.TLS, "ms")
+	if p.QUIC > 0 {
+		fmt.Fprintln(w, "QUIC:", p.QUIC, "ms")
+	}
+	fmt.Fprintln(w, "TTFB:", p.TTFB, "ms")
+	fmt.Fprintln(w, "Transfer:", p.Transfer, "ms")
+	fmt.Fprintln(w, "NewConnections:", p.NewConnections)
+}
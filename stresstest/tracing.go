@@ -0,0 +1,245 @@
+package stresstest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	otlpBatchSize     = 512
+	otlpFlushInterval = time.Second
+	// otlpQueueSize bounds the spans waiting for export. Spans beyond it are
+	// dropped rather than slowing the workers down.
+	otlpQueueSize = 16 * otlpBatchSize
+)
+
+// WithTracePropagation sends a W3C traceparent header with a new trace ID on
+// every request, so each one can be found in the server side traces. Retries
+// start a trace of their own.
+func (s *Stress) WithTracePropagation(enabled bool) *Stress {
+	s.TracePropagation = enabled
+	return s
+}
+
+// newTraceParent returns a sampled traceparent header value with random trace
+// and span IDs.
+func newTraceParent() string {
+	var ids [24]byte
+	rand.Read(ids[:])
+	return "00-" + hex.EncodeToString(ids[:16]) + "-" + hex.EncodeToString(ids[16:]) + "-01"
+}
+
+// OTLPConfig says where NewOTLPReporter exports spans.
+type OTLPConfig struct {
+	// Endpoint is the base URL of an OTLP/HTTP collector, such as
+	// http://localhost:4318; spans are posted to its /v1/traces.
+	Endpoint string
+	// Headers are sent with every export, for instance for authentication.
+	Headers map[string]string
+	// ServiceName defaults to "golang-stress-test".
+	ServiceName string
+}
+
+// NewOTLPReporter exports a client span for every request sent with trace
+// propagation on (see WithTracePropagation) to an OpenTelemetry collector,
+// using OTLP over HTTP with JSON encoding. The spans carry the IDs of the
+// traceparent header, so they are the parents of the server side spans.
+func NewOTLPReporter(cfg OTLPConfig) (Reporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("otlp: no endpoint")
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "golang-stress-test"
+	}
+	r := &otlpReporter{
+		cfg:     cfg,
+		url:     strings.TrimSuffix(cfg.Endpoint, "/") + "/v1/traces",
+		client:  &http.Client{Timeout: 10 * time.Second},
+		spans:   make(chan otlpSpan, otlpQueueSize),
+		stopped: make(chan struct{}),
+	}
+	go r.loop()
+	return r, nil
+}
+
+type otlpReporter struct {
+	cfg    OTLPConfig
+	url    string
+	client *http.Client
+
+	spans   chan otlpSpan
+	stopped chan struct{}
+	dropped atomic.Int64
+	failed  bool
+	errorOutput
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	otlpSpanKindClient  = 3
+	otlpStatusCodeOK    = 1
+	otlpStatusCodeError = 2
+)
+
+func stringAttribute(key string, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: map[string]any{"stringValue": value}}
+}
+
+func intAttribute(key string, value int) otlpAttribute {
+	// OTLP/JSON encodes 64 bit integers as strings.
+	return otlpAttribute{Key: key, Value: map[string]any{"intValue": strconv.Itoa(value)}}
+}
+
+func (r *otlpReporter) Collect(sample Sample) {
+	parts := strings.Split(sample.TraceParent, "-")
+	if len(parts) != 4 {
+		return
+	}
+	span := otlpSpan{
+		TraceID:           parts[1],
+		SpanID:            parts[2],
+		Name:              sample.Method,
+		Kind:              otlpSpanKindClient,
+		StartTimeUnixNano: strconv.FormatInt(sample.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(sample.Start.Add(sample.Latency).UnixNano(), 10),
+		Attributes: []otlpAttribute{
+			stringAttribute("http.request.method", sample.Method),
+			stringAttribute("url.full", sample.URL),
+			intAttribute("stresstest.worker", sample.Worker),
+		},
+		Status: otlpStatus{Code: otlpStatusCodeOK},
+	}
+	if sample.Target != "" {
+		span.Attributes = append(span.Attributes, stringAttribute("stresstest.target", sample.Target))
+	}
+	if sample.Status != 0 {
+		span.Attributes = append(span.Attributes, intAttribute("http.response.status_code", sample.Status))
+	}
+	if err := sample.Err; err != nil || sample.CheckErr != nil {
+		if err == nil {
+			err = sample.CheckErr
+		}
+		span.Status = otlpStatus{Code: otlpStatusCodeError, Message: err.Error()}
+	}
+
+	select {
+	case r.spans <- span:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// loop exports the queued spans in batches until the queue is closed.
+func (r *otlpReporter) loop() {
+	defer close(r.stopped)
+
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]otlpSpan, 0, otlpBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		// Only the first failure is reported, the others are likely the same.
+		if err := r.export(batch); err != nil && !r.failed {
+			r.printf("otlp: %v\n", err)
+			r.failed = true
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span, ok := <-r.spans:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) == otlpBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (r *otlpReporter) export(spans []otlpSpan) error {
+	payload := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": []otlpAttribute{stringAttribute("service.name", r.cfg.ServiceName)},
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "golang-stress-test"},
+				"spans": spans,
+			}},
+		}},
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range r.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("export returned %s: %s", res.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// Finalize exports the remaining spans. Spans are diagnostics, so failed
+// exports are reported on the error output of the Stress but do not fail the
+// run.
+func (r *otlpReporter) Finalize(*StressReport) error {
+	close(r.spans)
+	<-r.stopped
+	if dropped := r.dropped.Load(); dropped > 0 {
+		r.printf("otlp: dropped %d spans the exporter could not keep up with\n", dropped)
+	}
+	return nil
+}
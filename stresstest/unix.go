@@ -0,0 +1,13 @@
+package stresstest
+
+import "strings"
+
+// WithUnixSocket sends every connection to the Unix socket at path, given as
+// a file path or as unix:///path/to.sock, like curl --unix-socket. The URL
+// still sets the Host header and the request path, as in
+// http://localhost/health, so sidecars and local daemons can be tested. It
+// does not apply to WebSocket runs.
+func (s *Stress) WithUnixSocket(path string) *Stress {
+	s.UnixSocket = strings.TrimPrefix(path, "unix://")
+	return s
+}
@@ -0,0 +1,82 @@
+package stresstest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ResponseValidator inspects a response that met the success criteria, with
+// its body already read. A non-nil error fails the request and its message is
+// counted in the report.
+type ResponseValidator func(res *http.Response, body []byte) error
+
+// validationError marks the failures returned by a ResponseValidator.
+type validationError struct {
+	err error
+}
+
+func (e *validationError) Error() string { return e.err.Error() }
+
+func (e *validationError) Unwrap() error { return e.err }
+
+// WithResponseValidator runs validate on every response, for assertions the
+// success criteria cannot express, such as on JSON fields or headers.
+func (s *Stress) WithResponseValidator(validate ResponseValidator) *Stress {
+	s.Validator = validate
+	return s
+}
+
+// validate runs the success criteria, with the statuses spec expects if any,
+// then the header assertions and the validator.
+func (s *Stress) validate(spec requestSpec, res *http.Response, body []byte, latency time.Duration) error {
+	success := s.Success
+	if len(spec.ExpectStatus) > 0 {
+		success.StatusCodes = spec.ExpectStatus
+	}
+	if err := success.check(res, body, latency); err != nil {
+		return err
+	}
+	if err := s.checkHeaders(res); err != nil {
+		return err
+	}
+	if s.Validator == nil {
+		return nil
+	}
+	if err := s.Validator(res, body); err != nil {
+		return &validationError{err: err}
+	}
+	return nil
+}
+
+func (r *StressReport) addValidationError(err error) {
+	if r.ValidationErrors == nil {
+		r.ValidationErrors = make(map[string]int)
+	}
+	r.ValidationErrors[err.Error()]++
+}
+
+// writeValidationErrors lists the validation failures, most frequent first.
+func (r *StressReport) writeValidationErrors(w io.Writer) {
+	if len(r.ValidationErrors) == 0 {
+		return
+	}
+	messages := make([]string, 0, len(r.ValidationErrors))
+	for message := range r.ValidationErrors {
+		messages = append(messages, message)
+	}
+	slices.SortFunc(messages, func(a, b string) int {
+		if r.ValidationErrors[a] != r.ValidationErrors[b] {
+			return r.ValidationErrors[b] - r.ValidationErrors[a]
+		}
+		return strings.Compare(a, b)
+	})
+
+	fmt.Fprintln(w, "--- Validation errors ---")
+	for _, message := range messages {
+		fmt.Fprintln(w, message+":", r.ValidationErrors[message], "requests")
+	}
+}
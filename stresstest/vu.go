@@ -0,0 +1,67 @@
+package stresstest
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"text/template"
+)
+
+// VirtualUser is one simulated user. A worker, or an open model slot, acts as
+// the same user for the whole run, so its ID can be matched against server
+// logs through {{vu}} in templates and the verbose request log.
+type VirtualUser struct {
+	ID        int
+	client    *http.Client
+	row       map[string]string
+	templates templateCache
+}
+
+// WithFeederPerUser makes each virtual user take a single feeder row and use
+// it for all of its requests, like a user logged in with one account, instead
+// of taking a new row for every request.
+func (s *Stress) WithFeederPerUser(enabled bool) *Stress {
+	s.FeederPerUser = enabled
+	return s
+}
+
+// virtualUser returns the user with the given ID, creating it on first use.
+// With cookie jars enabled each user gets a copy of the shared client, on the
+// same transport, with its own jar.
+func (s *Stress) virtualUser(id int) *VirtualUser {
+	if vu, ok := s.users.Load(id); ok {
+		return vu.(*VirtualUser)
+	}
+
+	vu := s.newVirtualUser(id)
+	if s.FeederPerUser && s.Feeder != nil {
+		vu.row = s.Feeder.Next()
+	}
+
+	actual, _ := s.users.LoadOrStore(id, vu)
+	return actual.(*VirtualUser)
+}
+
+func (s *Stress) newVirtualUser(id int) *VirtualUser {
+	vu := &VirtualUser{ID: id, client: s.client}
+	vu.templates.funcs = template.FuncMap{"vu": func() int { return id }}
+	if s.CookieJar {
+		jar, _ := cookiejar.New(nil)
+		client := *s.client
+		client.Jar = jar
+		vu.client = &client
+	}
+	return vu
+}
+
+// templateData returns the feeder row the next request of vu is templated
+// with.
+func (s *Stress) templateData(vu *VirtualUser) map[string]string {
+	switch {
+	case s.Feeder == nil:
+		return map[string]string{}
+	case s.FeederPerUser:
+		return vu.row
+	default:
+		return s.Feeder.Next()
+	}
+}
@@ -0,0 +1,190 @@
+package stresstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WebhookFormat is the payload a webhook notification is sent as.
+type WebhookFormat string
+
+const (
+	// WebhookSlack posts a Slack incoming webhook message.
+	WebhookSlack WebhookFormat = "slack"
+	// WebhookTeams posts a Microsoft Teams incoming webhook message card.
+	WebhookTeams WebhookFormat = "teams"
+	// WebhookGeneric posts a JSON document with the summary and the full
+	// report.
+	WebhookGeneric WebhookFormat = "generic"
+)
+
+// WebhookConfig says where and how NewWebhookReporter notifies.
+type WebhookConfig struct {
+	URL string
+	// Format defaults to Slack for hooks.slack.com URLs, Teams for
+	// webhook.office.com ones and generic otherwise.
+	Format WebhookFormat
+	// Headers are sent with the notification, for instance for
+	// authentication.
+	Headers map[string]string
+	// Title heads the message, "Stress test" by default.
+	Title string
+	// OnlyFailures notifies only when thresholds are not met.
+	OnlyFailures bool
+}
+
+// NewWebhookReporter posts a summary of the final report, with whether the
+// thresholds were met, to a Slack, Teams or generic webhook once the run
+// ends, so scheduled runs can alert a team. A failed notification fails
+// Finalize.
+func NewWebhookReporter(cfg WebhookConfig) (Reporter, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("webhook: invalid URL %q", cfg.URL)
+	}
+	if cfg.Format == "" {
+		switch host := u.Hostname(); {
+		case host == "hooks.slack.com":
+			cfg.Format = WebhookSlack
+		case strings.HasSuffix(host, ".webhook.office.com"):
+			cfg.Format = WebhookTeams
+		default:
+			cfg.Format = WebhookGeneric
+		}
+	}
+	switch cfg.Format {
+	case WebhookSlack, WebhookTeams, WebhookGeneric:
+	default:
+		return nil, fmt.Errorf("webhook: unknown format %q, expected slack, teams or generic", cfg.Format)
+	}
+	if cfg.Title == "" {
+		cfg.Title = "Stress test"
+	}
+	return &webhookReporter{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+type webhookReporter struct {
+	cfg    WebhookConfig
+	client *http.Client
+}
+
+func (r *webhookReporter) Collect(Sample) {}
+
+func (r *webhookReporter) Finalize(report *StressReport) error {
+	failed := len(report.ThresholdViolations) > 0
+	if r.cfg.OnlyFailures && !failed {
+		return nil
+	}
+
+	verdict := "finished"
+	switch {
+	case report.Cancelled:
+		verdict = "cancelled"
+	case failed:
+		verdict = "failed"
+	case len(report.ThresholdResults) > 0:
+		verdict = "passed"
+	}
+	title := r.cfg.Title + ": " + verdict
+	facts := webhookFacts(report)
+
+	var payload any
+	switch r.cfg.Format {
+	case WebhookSlack:
+		icon := ":white_check_mark:"
+		if failed || report.Cancelled {
+			icon = ":x:"
+		}
+		var text strings.Builder
+		fmt.Fprintf(&text, "%s *%s*\n", icon, title)
+		for _, fact := range facts {
+			fmt.Fprintf(&text, "*%s:* %s\n", fact[0], fact[1])
+		}
+		payload = map[string]any{"text": text.String()}
+	case WebhookTeams:
+		color := "2EB67D"
+		if failed || report.Cancelled {
+			color = "E01E5A"
+		}
+		var teamsFacts []map[string]string
+		for _, fact := range facts {
+			teamsFacts = append(teamsFacts, map[string]string{"name": fact[0], "value": fact[1]})
+		}
+		payload = map[string]any{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    title,
+			"title":      title,
+			"themeColor": color,
+			"sections":   []any{map[string]any{"facts": teamsFacts}},
+		}
+	default:
+		lines := make([]string, 0, len(facts))
+		for _, fact := range facts {
+			lines = append(lines, fact[0]+": "+fact[1])
+		}
+		payload = map[string]any{
+			"title":   title,
+			"passed":  !failed,
+			"summary": strings.Join(lines, "\n"),
+			"report":  report,
+		}
+	}
+	if err := r.post(payload); err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	return nil
+}
+
+// webhookFacts returns the name/value lines of the summary.
+func webhookFacts(report *StressReport) [][2]string {
+	facts := [][2]string{
+		{"Requests", fmt.Sprintf("%d (%d succeeded, %d failed, %d timed out)", report.Requests, report.Succeeded, report.Failed, report.TimedOut)},
+		{"Error rate", fmt.Sprintf("%.2f %%", report.PercentageFailed+report.PercentageTimedOut)},
+		{"Rate", fmt.Sprintf("%.2f req/s", report.AchievedRate)},
+		{"Latency", fmt.Sprintf("p50 %.2f ms, p95 %.2f ms, p99 %.2f ms", report.P50, report.P95, report.P99)},
+		{"Duration", fmt.Sprintf("%.0f ms", report.TotalTime)},
+	}
+	for _, result := range report.ThresholdResults {
+		outcome := "pass"
+		if !result.Passed {
+			outcome = "FAIL"
+		}
+		facts = append(facts, [2]string{"Threshold " + result.Name, outcome + ", " + result.Message})
+	}
+	return facts
+}
+
+func (r *webhookReporter) post(payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, r.cfg.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range r.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("notification returned %s: %s", res.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package stresstest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// WebSocketStats describes the connections of a WebSocket run. The message
+// round trips themselves are recorded as the requests of the main report.
+// Times are in milliseconds.
+type WebSocketStats struct {
+	Connections       int     `json:"connections"`
+	ConnectFailures   int     `json:"connect_failures"`
+	Dropped           int     `json:"dropped"`
+	MessagesSent      int     `json:"messages_sent"`
+	MessagesReceived  int     `json:"messages_received"`
+	MessagesPerSecond float64 `json:"messages_per_second"`
+	ConnectAverage    float64 `json:"connect_average"`
+	ConnectP50        float64 `json:"connect_p50"`
+	ConnectP95        float64 `json:"connect_p95"`
+	ConnectP99        float64 `json:"connect_p99"`
+	connectLatencies  []time.Duration
+}
+
+func (w *WebSocketStats) finalize(elapsed time.Duration) {
+	if elapsed > 0 {
+		w.MessagesPerSecond = float64(w.MessagesReceived) / elapsed.Seconds()
+	}
+	if len(w.connectLatencies) == 0 {
+		return
+	}
+
+	sorted := slices.Clone(w.connectLatencies)
+	slices.Sort(sorted)
+	var sum time.Duration
+	for _, latency := range sorted {
+		sum += latency
+	}
+	w.ConnectAverage = milliseconds(sum / time.Duration(len(sorted)))
+	w.ConnectP50 = percentile(sorted, 50)
+	w.ConnectP95 = percentile(sorted, 95)
+	w.ConnectP99 = percentile(sorted, 99)
+}
+
+func (w *WebSocketStats) writeText(out io.Writer) {
+	fmt.Fprintln(out, "--- WebSocket ---")
+	fmt.Fprintln(out, "Connections:", w.Connections)
+	fmt.Fprintln(out, "ConnectFailures:", w.ConnectFailures)
+	fmt.Fprintln(out, "Dropped:", w.Dropped)
+	fmt.Fprintln(out, "MessagesSent:", w.MessagesSent)
+	fmt.Fprintln(out, "MessagesReceived:", w.MessagesReceived)
+	fmt.Fprintln(out, "MessagesPerSecond:", w.MessagesPerSecond)
+	fmt.Fprintln(out, "ConnectAverage:", w.ConnectAverage, "ms")
+	fmt.Fprintln(out, "ConnectP50:", w.ConnectP50, "ms")
+	fmt.Fprintln(out, "ConnectP95:", w.ConnectP95, "ms")
+	fmt.Fprintln(out, "ConnectP99:", w.ConnectP99, "ms")
+}
+
+// webSocketResponse stands in for the HTTP response of a message round trip,
+// so replies are counted under the status of the upgraded connection.
+var webSocketResponse = &http.Response{StatusCode: http.StatusSwitchingProtocols, Proto: "websocket"}
+
+// runWebSocket opens one connection per worker. Each message sent is one
+// request: it succeeds when the server answers with a message of its own, so
+// the target is expected to reply once to every message, as an echo server
+// does. A connection that breaks is counted as dropped and opened again.
+func (s *Stress) runWebSocket(ctx context.Context, wg *sync.WaitGroup) {
+	s.Report.WebSocket = &WebSocketStats{}
+
+	// Without a duration the run sends Requests messages in total, shared
+	// between the connections.
+	var slots chan struct{}
+	if s.Duration <= 0 {
+		if s.Requests <= 0 {
+			return
+		}
+		slots = newSlots(s.Requests)
+	}
+
+	for i := 0; i < s.Concurrency; i++ {
+		wg.Add(1)
+		i := i
+
+		go func() {
+			defer wg.Done()
+			if !sleepContext(ctx, s.rampUpDelay(i)) {
+				return
+			}
+			s.runWebSocketWorker(ctx, i+1, slots)
+		}()
+	}
+}
+
+func (s *Stress) runWebSocketWorker(ctx context.Context, worker int, slots chan struct{}) {
+	var conn *websocket.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for first := true; ctx.Err() == nil; first = false {
+		if slots != nil {
+			if _, ok := <-slots; !ok {
+				return
+			}
+		}
+		if !first && !sleepContext(ctx, s.thinkTime()) {
+			return
+		}
+		if s.limiter != nil {
+			if err := s.limiter.Wait(ctx); err != nil {
+				return
+			}
+		}
+
+		spec := s.targetSpec(s.pickTarget())
+		start := time.Now()
+		if conn == nil {
+			var err error
+			conn, err = s.dialWebSocket(ctx, spec)
+			if err != nil {
+				s.collect(Sample{Start: start, Worker: worker, Target: spec.Label, Method: "WS", URL: spec.URL, Err: err})
+				s.updateReport(spec, nil, err, nil, time.Since(start))
+				continue
+			}
+			start = time.Now()
+		}
+
+		rtt, err := s.roundTrip(conn, spec)
+		var reqErr *requestError
+		if err != nil && !errors.As(err, &reqErr) {
+			s.mu.Lock()
+			s.Report.WebSocket.Dropped++
+			s.mu.Unlock()
+			conn.Close()
+			conn = nil
+		}
+
+		sample := Sample{Start: start, Worker: worker, Target: spec.Label, Method: "WS", URL: spec.URL, Latency: rtt, Err: err}
+		if err == nil {
+			sample.Status = webSocketResponse.StatusCode
+		}
+		s.collect(sample)
+		if err != nil {
+			s.updateReport(spec, nil, err, nil, rtt)
+		} else {
+			s.updateReport(spec, webSocketResponse, nil, nil, rtt)
+		}
+	}
+}
+
+func (s *Stress) dialWebSocket(ctx context.Context, spec requestSpec) (*websocket.Conn, error) {
+	target, err := url.Parse(spec.URL)
+	if err != nil {
+		return nil, &requestError{err: err}
+	}
+	origin := &url.URL{Scheme: "http", Host: target.Host}
+	if target.Scheme == "wss" {
+		origin.Scheme = "https"
+	}
+
+	config, err := websocket.NewConfig(spec.URL, origin.String())
+	if err != nil {
+		return nil, &requestError{err: err}
+	}
+	config.TlsConfig, err = s.tlsConfig()
+	if err != nil {
+		return nil, &requestError{err: err}
+	}
+	// WebSocket connections use the DNS server but neither the DNS mode, the
+	// IP family nor the connect-to overrides.
+	localAddr, err := s.localAddr()
+	if err != nil {
+		return nil, &requestError{err: err}
+	}
+	config.Dialer = &net.Dialer{Timeout: s.connectTimeout(), Resolver: s.resolver(), LocalAddr: localAddr}
+	for _, headers := range []http.Header{s.Headers, spec.Headers} {
+		for key, values := range headers {
+			config.Header[http.CanonicalHeaderKey(key)] = values
+		}
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+	start := time.Now()
+	conn, err := config.DialContext(dialCtx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.Report.WebSocket.ConnectFailures++
+		// DialError does not unwrap, which would hide the cause from
+		// classifyError.
+		var dialErr *websocket.DialError
+		if errors.As(err, &dialErr) {
+			return nil, fmt.Errorf("websocket dial %s: %w", spec.URL, dialErr.Err)
+		}
+		return nil, err
+	}
+	s.Report.WebSocket.Connections++
+	s.Report.WebSocket.connectLatencies = append(s.Report.WebSocket.connectLatencies, time.Since(start))
+	return conn, nil
+}
+
+// roundTrip sends one message and waits for the reply.
+func (s *Stress) roundTrip(conn *websocket.Conn, spec requestSpec) (time.Duration, error) {
+	var message []byte
+	if spec.Body != nil {
+		body, err := spec.Body()
+		if err != nil {
+			return 0, &requestError{err: err}
+		}
+		message, err = io.ReadAll(body)
+		if err != nil {
+			return 0, &requestError{err: err}
+		}
+	}
+
+	start := time.Now()
+	conn.SetDeadline(start.Add(s.Timeout))
+	if err := websocket.Message.Send(conn, string(message)); err != nil {
+		return time.Since(start), err
+	}
+	s.mu.Lock()
+	s.Report.WebSocket.MessagesSent++
+	s.mu.Unlock()
+
+	var reply []byte
+	if err := websocket.Message.Receive(conn, &reply); err != nil {
+		return time.Since(start), err
+	}
+	rtt := time.Since(start)
+	s.mu.Lock()
+	s.Report.WebSocket.MessagesReceived++
+	s.mu.Unlock()
+	return rtt, nil
+}